@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/slack"
+	st "slack-rss-feed-manager/state"
+)
+
+// runDaemon handles the daemon subcommand: it keeps running, checking feeds
+// every configured interval, until it receives SIGINT or SIGTERM.
+func runDaemon(args []string) error {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	stateFile := flags.String("state", "state.json", "state file, or an SQLite database if it ends in .db")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// daemonLoop loads the config again; this only decides whether a Slack
+	// token is needed
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	slackClient, err := newSlackClient(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return daemonLoop(ctx, *configFile, *stateFile, slackClient, &defaultRSSClient{})
+}
+
+// daemonLoop runs cycles until ctx is cancelled. The state file stays locked
+// while the daemon runs. State is kept in memory and saved after every cycle,
+// including one cut short by cancellation. The config is reloaded before each
+// cycle so edits apply without a restart; if it no longer loads, the previous
+// config is kept.
+func daemonLoop(ctx context.Context, configFile, stateFile string, poster slack.Poster, rssClient RSSClient) error {
+	cfg, err := config.LoadMerged(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	lock, err := st.Lock(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer lock.Unlock()
+	store, err := st.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %w", err)
+	}
+	defer store.Close()
+	currentState, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	log.Printf("Daemon started: monitoring %d channels every %v", len(cfg.Channels), cfg.Daemon.PollInterval())
+
+	for {
+		startTime := time.Now()
+		queue := slack.NewQueue(ctx, poster, cfg.Slack.QueueOptions())
+		feedsProcessed, postsFound := runCycle(ctx, cfg, &currentState, queue, rssClient)
+		reportFailures(queue.Failures())
+		if err := store.Save(&currentState); err != nil {
+			log.Printf("Failed to save state: %v", err)
+		}
+		log.Printf("Cycle completed in %v: processed %d feeds, found %d new posts", time.Since(startTime), feedsProcessed, postsFound)
+
+		if ctx.Err() != nil {
+			log.Printf("Daemon stopping")
+			return nil
+		}
+		delay := nextDelay(cfg.Daemon)
+		log.Printf("Next check in %v", delay.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			log.Printf("Daemon stopping")
+			return nil
+		case <-time.After(delay):
+		}
+
+		if reloaded, err := config.LoadMerged(configFile); err != nil {
+			log.Printf("Failed to reload config, keeping previous config: %v", err)
+		} else {
+			cfg = reloaded
+		}
+	}
+}
+
+// nextDelay returns the interval shifted by a random amount within ±Jitter,
+// so that several instances don't hit the same hosts in lockstep.
+func nextDelay(d config.Daemon) time.Duration {
+	delay := d.PollInterval()
+	if d.Jitter > 0 {
+		delay += time.Duration(rand.Int64N(int64(2*d.Jitter))) - d.Jitter
+	}
+	return delay
+}
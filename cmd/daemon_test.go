@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/state"
+)
+
+func TestDaemonLoop(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	stateFile := filepath.Join(dir, "state.json")
+	err := os.WriteFile(configFile, []byte(`daemon:
+  interval: 20ms
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - http://example.com/feed
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockSlack := &mockSlackClient{}
+	mockRSS := &mockRSSClient{
+		items: []rss.FeedItem{
+			{Title: "Post", Link: "http://example.com/post", Published: time.Now()},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- daemonLoop(ctx, configFile, stateFile, mockSlack, mockRSS)
+	}()
+
+	// Wait for a few cycles, then stop
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mockRSS.mu.Lock()
+		fetches := len(mockRSS.fetched)
+		mockRSS.mu.Unlock()
+		if fetches >= 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("daemonLoop() error = %v", err)
+	}
+
+	mockRSS.mu.Lock()
+	fetches := len(mockRSS.fetched)
+	mockRSS.mu.Unlock()
+	if fetches < 3 {
+		t.Errorf("expected repeated cycles, got %d fetches", fetches)
+	}
+	// The new feed is set up in the first cycle and its post delivered once
+	if len(mockSlack.messages) != 1 {
+		t.Errorf("expected 1 message across cycles, got %d", len(mockSlack.messages))
+	}
+	saved, err := state.LoadState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved.Channels["test-channel"].Feeds["http://example.com/feed"]; !ok {
+		t.Error("expected state to be saved after cycles")
+	}
+}
+
+func TestDaemonLoopInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	err := daemonLoop(context.Background(), filepath.Join(dir, "missing.yaml"), filepath.Join(dir, "state.json"), &mockSlackClient{}, &mockRSSClient{})
+	if err == nil {
+		t.Error("expected error for missing config")
+	}
+}
+
+func TestNextDelay(t *testing.T) {
+	if got := nextDelay(config.Daemon{}); got != config.DefaultDaemonInterval {
+		t.Errorf("expected default interval, got %v", got)
+	}
+
+	d := config.Daemon{Interval: time.Hour, Jitter: 5 * time.Minute}
+	for i := 0; i < 100; i++ {
+		got := nextDelay(d)
+		if got < 55*time.Minute || got > 65*time.Minute {
+			t.Fatalf("delay %v outside interval ± jitter", got)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"strings"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	st "slack-rss-feed-manager/state"
+)
+
+const (
+	addUsage      = "usage: add [-config file] [-name name] <channel> <feed-url>"
+	removeUsage   = "usage: remove [-config file] [-force] <channel> <feed-url>"
+	listUsage     = "usage: list [-config file] [-state file]"
+	validateUsage = "usage: validate [-config file]"
+	checkUsage    = "usage: check [-config file] <feed-url>"
+)
+
+// runAdd handles the add subcommand. The feed is fetched first, and only added
+// to the config if it can be fetched and parsed.
+func runAdd(args []string, stdout io.Writer, rssClient RSSClient) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	name := flags.String("name", "", "name shown in posts instead of the feed's title")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New(addUsage)
+	}
+	channel, feed := strings.TrimPrefix(flags.Arg(0), "#"), config.Feed{URL: flags.Arg(1), Name: *name}
+
+	cfg, err := config.LoadConfig(*configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Config %s does not exist, creating it", *configFile)
+		cfg, err = config.Config{}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	result, err := verifyFeed(cfg, feed, rssClient)
+	if err != nil {
+		return err
+	}
+	if !cfg.AddFeed(channel, feed) {
+		return fmt.Errorf("channel %s already has feed %s", channel, feed.URL)
+	}
+	if err := config.SaveConfig(*configFile, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Fprintf(stdout, "Added %s to #%s (%d items)\n", feedTitle(result.Items, feed.URL), channel, len(result.Items))
+	return nil
+}
+
+// runRemove handles the remove subcommand, for feeds in the config file as
+// well as those subscribed from Slack. Their state is dropped on the next run.
+// Removing a channel's last feed removes the channel, so that needs -force
+// when the channel has settings that would go with it.
+func runRemove(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("remove", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	force := flags.Bool("force", false, "remove a channel's last feed even if the channel's settings go with it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New(removeUsage)
+	}
+	channel, feedURL := strings.TrimPrefix(flags.Arg(0), "#"), flags.Arg(1)
+
+	cfg, err := config.LoadConfig(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if ch := cfg.Channel(channel); ch != nil && len(ch.Feeds) == 1 && ch.Feeds[0].URL == feedURL {
+		if settings := ch.Settings(); len(settings) > 0 && !*force {
+			return fmt.Errorf("%s is the last feed of #%s, so removing it also removes the channel's %s; use -force to remove it anyway",
+				feedURL, channel, strings.Join(settings, ", "))
+		}
+	}
+	if cfg.RemoveFeed(channel, feedURL) {
+		if err := config.SaveConfig(*configFile, cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if cfg.Channel(channel) == nil {
+			fmt.Fprintf(stdout, "#%s has no feeds left and was removed from the config\n", channel)
+		}
+	} else {
+		removed, err := removeSubscription(cfg, channel, feedURL)
+		if err != nil {
+			return fmt.Errorf("failed to update subscriptions: %w", err)
+		}
+		if !removed {
+			return fmt.Errorf("channel %s has no feed %s", channel, feedURL)
+		}
+	}
+	fmt.Fprintf(stdout, "Removed %s from #%s\n", feedURL, channel)
+	return nil
+}
+
+// removeSubscription removes a feed subscribed from Slack, if the config has
+// a subscriptions file.
+func removeSubscription(cfg config.Config, channel, feedURL string) (bool, error) {
+	if cfg.Subscriptions == "" {
+		return false, nil
+	}
+	subs, err := config.LoadSubscriptions(cfg.Subscriptions)
+	if err != nil {
+		return false, err
+	}
+	subs, removed := config.RemoveSubscription(subs, channel, feedURL)
+	if !removed {
+		return false, nil
+	}
+	return true, config.SaveSubscriptions(cfg.Subscriptions, subs)
+}
+
+// runList handles the list subcommand, printing each channel's feeds with
+// what the state knows about them.
+func runList(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	stateFile := flags.String("state", "state.json", "state file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(listUsage)
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := st.OpenReadOnly(*stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %w", err)
+	}
+	defer store.Close()
+	state, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	for _, ch := range cfg.Channels {
+		fmt.Fprintf(stdout, "#%s\n", ch.SlackChannel)
+		for _, feed := range ch.Feeds {
+			var notes []string
+			if feed.Name != "" {
+				notes = append(notes, fmt.Sprintf("%q", feed.Name))
+			}
+			if !feed.IsEnabled() {
+				notes = append(notes, "disabled")
+			}
+			feedState, ok := state.Channels[ch.SlackChannel].Feeds[feed.URL]
+			if !ok {
+				notes = append(notes, "not checked yet")
+			} else {
+				if !feedState.LastChecked.IsZero() {
+					notes = append(notes, "checked "+feedState.LastChecked.Format(time.RFC3339))
+				}
+				notes = append(notes, "last post "+feedState.LastUpdated.Format(time.RFC3339))
+				if len(feedState.Pending) > 0 {
+					notes = append(notes, fmt.Sprintf("%d pending", len(feedState.Pending)))
+				}
+			}
+			fmt.Fprintf(stdout, "  %s  (%s)\n", feed.URL, strings.Join(notes, ", "))
+		}
+	}
+	return nil
+}
+
+// runValidate handles the validate subcommand.
+func runValidate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(validateUsage)
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("%s is not valid: %w", *configFile, err)
+	}
+	feeds := 0
+	for _, ch := range cfg.Channels {
+		feeds += len(ch.Feeds)
+	}
+	fmt.Fprintf(stdout, "%s is valid: %d channels, %d feeds\n", *configFile, len(cfg.Channels), feeds)
+	return nil
+}
+
+// runCheck handles the check subcommand, fetching a feed and summarizing it.
+// Headers and timeouts are taken from the config when the feed is in it.
+func runCheck(args []string, stdout io.Writer, rssClient RSSClient) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(checkUsage)
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		cfg, err = config.Config{}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	_, feed, err := findFeed(&cfg, "", flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	result, err := verifyFeed(cfg, feed, rssClient)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Feed:    %s\n", feedTitle(result.Items, feed.URL))
+	fmt.Fprintf(stdout, "Items:   %d\n", len(result.Items))
+	if latest, ok := latestItem(result.Items); ok {
+		fmt.Fprintf(stdout, "Latest:  %s (%s)\n", latest.Title, latest.Published.Format(time.RFC3339))
+	}
+	var caching []string
+	if result.Cache.ETag != "" {
+		caching = append(caching, "ETag")
+	}
+	if result.Cache.LastModified != "" {
+		caching = append(caching, "Last-Modified")
+	}
+	if len(caching) == 0 {
+		caching = append(caching, "none")
+	}
+	fmt.Fprintf(stdout, "Caching: %s\n", strings.Join(caching, ", "))
+	return nil
+}
+
+// verifyFeed fetches feed once to make sure it can be fetched and parsed.
+func verifyFeed(cfg config.Config, feed config.Feed, rssClient RSSClient) (rss.FetchResult, error) {
+	outcome := fetchOne(context.Background(), rssClient, fetchJob{
+		request: rss.Request{URL: feed.URL, Headers: feed.Headers, PublicOnly: feed.FromSlack},
+		timeout: cfg.FetchTimeout(feed),
+	})
+	if outcome.err != nil {
+		return rss.FetchResult{}, fmt.Errorf("failed to fetch %s: %w", feed.URL, outcome.err)
+	}
+	if len(outcome.result.Items) == 0 {
+		log.Printf("Warning: feed %s has no items", feed.URL)
+	}
+	return outcome.result, nil
+}
+
+// feedTitle returns the feed's own title, or fallback if it has none.
+func feedTitle(items []rss.FeedItem, fallback string) string {
+	for _, item := range items {
+		if item.FeedTitle != "" {
+			return item.FeedTitle
+		}
+	}
+	return fallback
+}
+
+// latestItem returns the most recently published item.
+func latestItem(items []rss.FeedItem) (rss.FeedItem, bool) {
+	if len(items) == 0 {
+		return rss.FeedItem{}, false
+	}
+	latest := items[0]
+	for _, item := range items[1:] {
+		if item.Published.After(latest.Published) {
+			latest = item
+		}
+	}
+	return latest, true
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+)
+
+func TestFeedCommands(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	stateFile := filepath.Join(dir, "state.json")
+	err := os.WriteFile(configFile, []byte(`# Our feeds
+channels:
+  - slack_channel: general
+    feeds:
+      - https://example.com/a.xml # keep this one
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	mockRSS := &mockRSSClient{
+		items: []rss.FeedItem{{Title: "Hello", Link: "http://example.com/1", FeedTitle: "Example", Published: published}},
+		cache: rss.CacheInfo{ETag: `"v1"`},
+	}
+	var out bytes.Buffer
+
+	if err := runAdd([]string{"-config", configFile, "-name", "Ex", "#general", "https://example.com/b.xml"}, &out, mockRSS); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+	if err := runAdd([]string{"-config", configFile, "general", "https://example.com/b.xml"}, &out, mockRSS); err == nil {
+		t.Error("Expected error adding a feed twice")
+	}
+	if err := runAdd([]string{"-config", configFile, "general", "https://example.com/broken.xml"}, &out, &mockRSSClient{err: errors.New("parse error")}); err == nil {
+		t.Error("Expected error adding a feed that can't be fetched")
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feeds := cfg.Channels[0].Feeds; len(feeds) != 2 || feeds[1].URL != "https://example.com/b.xml" || feeds[1].Name != "Ex" {
+		t.Errorf("Expected only the working feed to be added, got %+v", feeds)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# Our feeds") || !strings.Contains(string(data), "# keep this one") {
+		t.Errorf("Expected comments to be kept, got:\n%s", data)
+	}
+
+	out.Reset()
+	if err := runList([]string{"-config", configFile, "-state", stateFile}, &out); err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	want := "#general\n  https://example.com/a.xml  (not checked yet)\n  https://example.com/b.xml  (\"Ex\", not checked yet)\n"
+	if out.String() != want {
+		t.Errorf("list output = %q, want %q", out.String(), want)
+	}
+
+	// Listing reads state without creating an SQLite database
+	out.Reset()
+	dbFile := filepath.Join(dir, "state.db")
+	if err := runList([]string{"-config", configFile, "-state", dbFile}, &out); err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("list output = %q, want %q", out.String(), want)
+	}
+	if _, err := os.Stat(dbFile); !os.IsNotExist(err) {
+		t.Errorf("Expected list not to create %s, got %v", dbFile, err)
+	}
+
+	out.Reset()
+	if err := runCheck([]string{"-config", configFile, "https://example.com/b.xml"}, &out, mockRSS); err != nil {
+		t.Fatalf("check error = %v", err)
+	}
+	for _, line := range []string{"Feed:    Example", "Items:   1", "Latest:  Hello (2025-07-25T12:00:00Z)", "Caching: ETag"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("check output missing %q:\n%s", line, out.String())
+		}
+	}
+
+	if err := runRemove([]string{"-config", configFile, "general", "https://example.com/a.xml"}, &out); err != nil {
+		t.Fatalf("remove error = %v", err)
+	}
+	if err := runRemove([]string{"-config", configFile, "general", "https://example.com/a.xml"}, &out); err == nil {
+		t.Error("Expected error removing a feed that isn't there")
+	}
+
+	out.Reset()
+	if err := runValidate([]string{"-config", configFile}, &out); err != nil {
+		t.Fatalf("validate error = %v", err)
+	}
+	if out.String() != configFile+" is valid: 1 channels, 1 feeds\n" {
+		t.Errorf("Unexpected validate output %q", out.String())
+	}
+	if err := os.WriteFile(configFile, []byte("channels: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runValidate([]string{"-config", configFile}, &out); err == nil {
+		t.Error("Expected error validating an empty config")
+	}
+}
+
+func TestRemoveLastFeed(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`channels:
+  - slack_channel: general
+    feeds:
+      - https://example.com/a.xml
+  - slack_channel: digest
+    digest: {every: daily}
+    feeds:
+      - https://example.com/b.xml
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+
+	err = runRemove([]string{"-config", configFile, "digest", "https://example.com/b.xml"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "digest") || !strings.Contains(err.Error(), "-force") {
+		t.Errorf("Expected removing the last feed of a channel with settings to be refused, got %v", err)
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch := cfg.Channel("digest"); ch == nil || len(ch.Feeds) != 1 {
+		t.Fatalf("Expected the channel to be kept, got %+v", cfg.Channels)
+	}
+
+	if err := runRemove([]string{"-config", configFile, "-force", "digest", "https://example.com/b.xml"}, &out); err != nil {
+		t.Fatalf("remove -force error = %v", err)
+	}
+	if !strings.Contains(out.String(), "#digest has no feeds left") {
+		t.Errorf("Expected a note about the removed channel, got %q", out.String())
+	}
+	cfg, err = config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Channels) != 1 || cfg.Channels[0].SlackChannel != "general" {
+		t.Errorf("Expected only #general to remain, got %+v", cfg.Channels)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+type fetchJob struct {
+	request rss.Request
+	timeout time.Duration
+}
+
+type fetchOutcome struct {
+	result rss.FetchResult
+	err    error
+}
+
+// fetchAll fetches every job concurrently, with at most concurrency requests in
+// flight overall and perHost to any single host. Outcomes are returned in job
+// order so callers can post deterministically. Jobs carry everything a fetch
+// needs, so the workers never touch the shared state. Jobs still waiting for a
+// slot when ctx is cancelled fail with the context's error.
+func fetchAll(ctx context.Context, jobs []fetchJob, rssClient RSSClient, concurrency, perHost int) []fetchOutcome {
+	outcomes := make([]fetchOutcome, len(jobs))
+	global := make(chan struct{}, concurrency)
+	hosts := make(map[string]chan struct{})
+	for _, job := range jobs {
+		host := hostOf(job.request.URL)
+		if _, ok := hosts[host]; !ok {
+			hosts[host] = make(chan struct{}, perHost)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job fetchJob) {
+			defer wg.Done()
+			// Take the host slot first so jobs queued behind a busy host don't
+			// hold global slots other hosts could use.
+			hostSem := hosts[hostOf(job.request.URL)]
+			if err := acquire(ctx, hostSem); err != nil {
+				outcomes[i] = fetchOutcome{err: err}
+				return
+			}
+			defer func() { <-hostSem }()
+			if err := acquire(ctx, global); err != nil {
+				outcomes[i] = fetchOutcome{err: err}
+				return
+			}
+			defer func() { <-global }()
+
+			outcomes[i] = fetchOne(ctx, rssClient, job)
+		}(i, job)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+func fetchOne(ctx context.Context, rssClient RSSClient, job fetchJob) fetchOutcome {
+	if job.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.timeout)
+		defer cancel()
+	}
+	result, err := rssClient.FetchFeed(ctx, job.request)
+	return fetchOutcome{result: result, err: err}
+}
+
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hostOf(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Host == "" {
+		return feedURL
+	}
+	return u.Host
+}
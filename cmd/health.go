@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/slack"
+	st "slack-rss-feed-manager/state"
+)
+
+const reportUsage = "usage: report [-config file] [-state file]"
+
+// recordFetch updates a feed's health after a fetch, and tells the alerts
+// channel when the feed reaches the failure threshold or recovers after it.
+func recordFetch(cfg config.Config, feedState *st.FeedState, slackClient SlackClient, channel, feedURL string, outcome fetchOutcome) {
+	threshold := cfg.Alerts.Threshold()
+	// Zero when no response arrived, so an old status isn't shown next to a
+	// connection error
+	feedState.LastStatus = outcome.result.StatusCode
+
+	if outcome.err != nil {
+		feedState.Failures++
+		feedState.LastError = outcome.err.Error()
+		if feedState.Failures == threshold {
+			sendAlert(cfg, slackClient, fmt.Sprintf(":warning: Feed %s for #%s has failed %d times in a row (last success: %s): %s",
+				feedURL, channel, feedState.Failures, lastSuccess(*feedState), feedState.LastError))
+		}
+		return
+	}
+
+	if feedState.Failures >= threshold {
+		sendAlert(cfg, slackClient, fmt.Sprintf(":white_check_mark: Feed %s for #%s is working again after %d failed fetches",
+			feedURL, channel, feedState.Failures))
+	}
+	feedState.Failures = 0
+	feedState.LastError = ""
+}
+
+// sendAlert posts to the alerts channel, if one is configured.
+func sendAlert(cfg config.Config, slackClient SlackClient, text string) {
+	log.Print(text)
+	if cfg.Alerts.Channel == "" {
+		return
+	}
+	if _, _, err := slackClient.PostMessage("#"+cfg.Alerts.Channel, slack.Message{Text: text}); err != nil {
+		log.Printf("Error posting alert to #%s: %v", cfg.Alerts.Channel, err)
+	}
+}
+
+func lastSuccess(feedState st.FeedState) string {
+	if feedState.LastChecked.IsZero() {
+		return "never"
+	}
+	return feedState.LastChecked.Format(time.RFC3339)
+}
+
+// runReport handles the report subcommand, listing the feeds whose latest
+// fetches failed.
+func runReport(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	stateFile := flags.String("state", "state.json", "state file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(reportUsage)
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := st.OpenReadOnly(*stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %w", err)
+	}
+	defer store.Close()
+	state, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	unhealthy := 0
+	threshold := cfg.Alerts.Threshold()
+	for _, ch := range cfg.Channels {
+		for _, feed := range ch.Feeds {
+			feedState := state.Channels[ch.SlackChannel].Feeds[feed.URL]
+			if feedState.Failures == 0 {
+				continue
+			}
+			unhealthy++
+			status := "failing"
+			if feedState.Failures >= threshold {
+				status = "down"
+			}
+			fmt.Fprintf(stdout, "%s #%s %s\n", status, ch.SlackChannel, feed.URL)
+			fmt.Fprintf(stdout, "  %d failures in a row, last success: %s", feedState.Failures, lastSuccess(feedState))
+			if feedState.LastStatus != 0 {
+				fmt.Fprintf(stdout, ", last HTTP status: %d", feedState.LastStatus)
+			}
+			fmt.Fprintf(stdout, "\n  %s\n", feedState.LastError)
+		}
+	}
+	if unhealthy == 0 {
+		fmt.Fprintln(stdout, "All feeds are healthy")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/state"
+)
+
+func TestFeedHealthAlerts(t *testing.T) {
+	feedURL := "http://example.com/feed"
+	cfg := config.Config{
+		Alerts: config.Alerts{Channel: "rss-admin", FailureThreshold: 2},
+		Channels: []config.Channel{
+			{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: feedURL}}},
+		},
+	}
+	currentState := state.State{Channels: map[string]state.ChannelState{
+		"test-channel": {Feeds: map[string]state.FeedState{feedURL: {LastUpdated: time.Now()}}},
+	}}
+	feedState := func() state.FeedState {
+		return currentState.Channels["test-channel"].Feeds[feedURL]
+	}
+	mockSlack := &mockSlackClient{}
+	failing := &mockRSSClient{err: errors.New("connection refused")}
+
+	for range 3 {
+		processFeeds(context.Background(), cfg, &currentState, mockSlack, failing)
+	}
+	if feedState().Failures != 3 || feedState().LastError != "connection refused" {
+		t.Errorf("Expected 3 failures to be recorded, got %+v", feedState())
+	}
+	if len(mockSlack.messages) != 1 || mockSlack.messages[0].channel != "#rss-admin" || !contains(mockSlack.messages[0].text, "failed 2 times") {
+		t.Fatalf("Expected one alert at the threshold, got %+v", mockSlack.messages)
+	}
+
+	mockSlack = &mockSlackClient{}
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, &mockRSSClient{})
+	if feedState().Failures != 0 || feedState().LastError != "" {
+		t.Errorf("Expected failures to be reset, got %+v", feedState())
+	}
+	if len(mockSlack.messages) != 1 || !contains(mockSlack.messages[0].text, "working again after 3 failed fetches") {
+		t.Errorf("Expected a recovery alert, got %+v", mockSlack.messages)
+	}
+}
+
+func TestRecordFetch(t *testing.T) {
+	cfg := config.Config{}
+	mockSlack := &mockSlackClient{}
+	var feedState state.FeedState
+
+	recordFetch(cfg, &feedState, mockSlack, "general", "http://example.com/feed", fetchOutcome{
+		result: rss.FetchResult{StatusCode: 503},
+		err:    errors.New("unexpected HTTP status 503 Service Unavailable"),
+	})
+	if feedState.Failures != 1 || feedState.LastStatus != 503 {
+		t.Errorf("Unexpected health %+v", feedState)
+	}
+
+	// Without an alerts channel failures are only logged
+	for range config.DefaultFailureThreshold {
+		recordFetch(cfg, &feedState, mockSlack, "general", "http://example.com/feed", fetchOutcome{err: errors.New("timeout")})
+	}
+	if len(mockSlack.messages) != 0 {
+		t.Errorf("Expected no alerts without a channel, got %+v", mockSlack.messages)
+	}
+	if feedState.LastStatus != 0 {
+		t.Errorf("Expected no HTTP status when there was no response, got %d", feedState.LastStatus)
+	}
+
+	recordFetch(cfg, &feedState, mockSlack, "general", "http://example.com/feed", fetchOutcome{result: rss.FetchResult{StatusCode: 200}})
+	if feedState.Failures != 0 || feedState.LastStatus != 200 {
+		t.Errorf("Unexpected health after success %+v", feedState)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	stateFile := filepath.Join(dir, "state.json")
+	cfg := config.Config{Channels: []config.Channel{
+		{SlackChannel: "general", Feeds: []config.Feed{{URL: "http://example.com/ok"}, {URL: "http://example.com/down"}}},
+	}}
+	if err := config.SaveConfig(configFile, cfg); err != nil {
+		t.Fatal(err)
+	}
+	s := state.State{Channels: map[string]state.ChannelState{
+		"general": {Feeds: map[string]state.FeedState{
+			"http://example.com/ok":   {LastChecked: time.Now()},
+			"http://example.com/down": {Failures: 4, LastError: "unexpected HTTP status 410 Gone", LastStatus: 410},
+		}},
+	}}
+	if err := s.Save(stateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runReport([]string{"-config", configFile, "-state", stateFile}, &out); err != nil {
+		t.Fatalf("report error = %v", err)
+	}
+	want := "down #general http://example.com/down\n  4 failures in a row, last success: never, last HTTP status: 410\n  unexpected HTTP status 410 Gone\n"
+	if out.String() != want {
+		t.Errorf("report output = %q, want %q", out.String(), want)
+	}
+
+	s.Channels["general"].Feeds["http://example.com/down"] = state.FeedState{}
+	if err := s.Save(stateFile); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := runReport([]string{"-config", configFile, "-state", stateFile}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "All feeds are healthy") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
@@ -1,72 +1,167 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/filter"
+	"slack-rss-feed-manager/notify"
+	"slack-rss-feed-manager/render"
 	"slack-rss-feed-manager/rss"
 	"slack-rss-feed-manager/slack"
 	st "slack-rss-feed-manager/state"
 )
 
 type SlackClient interface {
-	PostMessage(channel, text string) error
+	PostMessage(channel string, msg slack.Message) (string, string, error)
+	UpdateMessage(channelID, ts string, msg slack.Message) error
 }
 
 type RSSClient interface {
-	FetchFeed(url string, lastUpdated time.Time) ([]rss.FeedItem, time.Time, error)
+	FetchFeed(ctx context.Context, req rss.Request) (rss.FetchResult, error)
 }
 
 type defaultRSSClient struct{}
 
-func (c *defaultRSSClient) FetchFeed(url string, lastUpdated time.Time) ([]rss.FeedItem, time.Time, error) {
-	return rss.FetchFeed(url, lastUpdated)
+func (c *defaultRSSClient) FetchFeed(ctx context.Context, req rss.Request) (rss.FetchResult, error) {
+	return rss.FetchFeed(ctx, req)
 }
 
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		var err error
+		switch os.Args[1] {
+		case "opml":
+			err = runOPML(os.Args[2:], os.Stdout)
+		case "daemon":
+			err = runDaemon(os.Args[2:])
+		case "preview":
+			err = runPreview(os.Args[2:], os.Stdout, &defaultRSSClient{})
+		case "add":
+			err = runAdd(os.Args[2:], os.Stdout, &defaultRSSClient{})
+		case "remove":
+			err = runRemove(os.Args[2:], os.Stdout)
+		case "list":
+			err = runList(os.Args[2:], os.Stdout)
+		case "validate":
+			err = runValidate(os.Args[2:], os.Stdout)
+		case "check":
+			err = runCheck(os.Args[2:], os.Stdout, &defaultRSSClient{})
+		case "serve":
+			err = runServe(os.Args[2:])
+		case "report":
+			err = runReport(os.Args[2:], os.Stdout)
+		default:
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configFlag := flags.String("config", "config.yaml", "config file")
+	stateFlag := flags.String("state", "state.json", "state file, or an SQLite database if it ends in .db")
+	dryRun := flags.Bool("dry-run", false, "print messages to stdout instead of posting them, and don't save state")
+	format := flags.String("format", "text", "output format for -dry-run: text or json")
+	flags.Parse(os.Args[1:])
+
 	startTime := time.Now()
 	log.Printf("RSS Feed Manager starting at %s", startTime.Format(time.RFC3339))
 
-	token := os.Getenv("SLACK_BOT_TOKEN")
-	if token == "" {
-		log.Fatal("SLACK_BOT_TOKEN not set")
+	var slackClient slack.Poster
+	if *dryRun {
+		printer, err := newMessagePrinter(os.Stdout, *format)
+		if err != nil {
+			log.Fatal(err)
+		}
+		slackClient = printer
+		log.Printf("Dry run: printing messages instead of posting, state will not be saved")
 	}
-	slackClient := slack.NewClient(token)
-	log.Printf("Slack client initialized")
 
-	configFile := "config.yaml"
-	stateFile := "state.json"
+	configFile := *configFlag
+	stateFile := *stateFlag
 
 	// Load config
 	log.Printf("Loading config from %s", configFile)
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := config.LoadMerged(configFile)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 	log.Printf("Config loaded successfully: monitoring %d channels", len(cfg.Channels))
+	if !*dryRun {
+		slackClient, err = newSlackClient(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// Load state
+	// Hold the state lock for the whole run so a concurrent run can't overwrite
+	// our state. A dry run never writes, so it doesn't need it.
+	if !*dryRun {
+		lock, err := st.Lock(stateFile)
+		if err != nil {
+			log.Fatalf("Failed to lock state: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
+	// Load state. A dry run opens it read-only, so a missing or outdated
+	// SQLite database isn't created or upgraded either.
 	log.Printf("Loading state from %s", stateFile)
-	currentState, err := st.LoadState(stateFile)
+	open := st.Open
+	if *dryRun {
+		open = st.OpenReadOnly
+	}
+	store, err := open(stateFile)
+	if err != nil {
+		log.Fatalf("Failed to open state: %v", err)
+	}
+	defer store.Close()
+	currentState, err := store.Load()
 	if err != nil {
 		log.Fatalf("Failed to load state: %v", err)
 	}
 	log.Printf("State loaded successfully")
 
-	// Update subscriptions and process feeds
-	log.Printf("Updating subscriptions...")
-	rssClient := &defaultRSSClient{}
-	updateSubscriptions(cfg, &currentState, rssClient)
-	log.Printf("Processing feeds...")
-	feedsProcessed, postsFound := processFeeds(cfg, &currentState, slackClient, rssClient)
+	// Stop in-flight work on SIGINT/SIGTERM; whatever state was gathered is still saved below
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Printed messages need no spacing or retries
+	poster := slackClient
+	var queue *slack.Queue
+	if !*dryRun {
+		queue = slack.NewQueue(ctx, slackClient, cfg.Slack.QueueOptions())
+		poster = queue
+	}
+	feedsProcessed, postsFound := runCycle(ctx, cfg, &currentState, poster, &defaultRSSClient{})
+	if queue != nil {
+		reportFailures(queue.Failures())
+	}
+	if ctx.Err() != nil {
+		log.Printf("Interrupted, saving progress so far")
+	}
 
 	// Save updated state
-	log.Printf("Saving updated state to %s", stateFile)
-	if err := currentState.Save(stateFile); err != nil {
-		log.Fatalf("Failed to save state: %v", err)
+	if *dryRun {
+		log.Printf("Dry run: not saving state")
+	} else {
+		log.Printf("Saving updated state to %s", stateFile)
+		if err := store.Save(&currentState); err != nil {
+			log.Fatalf("Failed to save state: %v", err)
+		}
 	}
 
 	duration := time.Since(startTime)
@@ -74,7 +169,57 @@ func main() {
 	log.Printf("Summary: Processed %d feeds, found %d new posts", feedsProcessed, postsFound)
 }
 
-func updateSubscriptions(cfg config.Config, state *st.State, rssClient RSSClient) {
+// newSlackClient returns the client for SLACK_BOT_TOKEN. The token is only
+// required when cfg posts something through the Slack bot; configs that only
+// use other targets get a client that fails every post instead.
+func newSlackClient(cfg config.Config) (slack.Poster, error) {
+	token := os.Getenv("SLACK_BOT_TOKEN")
+	if token == "" {
+		if cfg.UsesSlackBot() {
+			return nil, errNoSlackToken
+		}
+		log.Printf("No channel posts through the Slack bot, running without SLACK_BOT_TOKEN")
+		return noSlackClient{}, nil
+	}
+	slackClient := slack.NewClient(token)
+	log.Printf("Slack client initialized")
+	return slackClient, nil
+}
+
+var errNoSlackToken = errors.New("SLACK_BOT_TOKEN not set")
+
+// noSlackClient stands in for the Slack client when there is no token, in
+// case the config is changed to post to Slack while the daemon runs.
+type noSlackClient struct{}
+
+func (noSlackClient) PostMessage(channel string, msg slack.Message) (string, string, error) {
+	return "", "", errNoSlackToken
+}
+
+func (noSlackClient) UpdateMessage(channelID, ts string, msg slack.Message) error {
+	return errNoSlackToken
+}
+
+// runCycle brings the state in line with the config and posts new items once.
+func runCycle(ctx context.Context, cfg config.Config, state *st.State, slackClient SlackClient, rssClient RSSClient) (int, int) {
+	log.Printf("Updating subscriptions...")
+	updateSubscriptions(ctx, cfg, state, rssClient)
+	log.Printf("Processing feeds...")
+	return processFeeds(ctx, cfg, state, slackClient, rssClient)
+}
+
+// reportFailures logs the messages that could not be posted even after retries.
+func reportFailures(failures []slack.Failure) {
+	if len(failures) == 0 {
+		return
+	}
+	log.Printf("%d messages could not be posted:", len(failures))
+	for _, f := range failures {
+		log.Printf("  %s: %v: %s", f.Channel, f.Err, f.Text)
+	}
+}
+
+func updateSubscriptions(ctx context.Context, cfg config.Config, state *st.State, rssClient RSSClient) {
 	for _, ch := range cfg.Channels {
 		if _, ok := state.Channels[ch.SlackChannel]; !ok {
 			log.Printf("Adding new channel to state: %s", ch.SlackChannel)
@@ -82,13 +227,21 @@ func updateSubscriptions(cfg config.Config, state *st.State, rssClient RSSClient
 		}
 		channelState := state.Channels[ch.SlackChannel]
 		// Add new feeds
-		for _, feed := range ch.Feeds {
-			if _, ok := channelState.Feeds[feed]; !ok {
+		for _, f := range ch.Feeds {
+			feed := f.URL
+			// Disabled feeds are set up once they are enabled
+			if _, ok := channelState.Feeds[feed]; !ok && f.IsEnabled() {
 				log.Printf("Adding new feed to channel %s: %s", ch.SlackChannel, feed)
 				
 				// For new feeds, fetch the latest post and set LastUpdated to 1 hour before it
-				// This ensures the most recent post will be picked up in the next processing cycle
-				items, _, err := rssClient.FetchFeed(feed, time.Time{}) // Use zero time to get all items
+				// This ensures the most recent post will be picked up in the next processing cycle.
+				// The cache validators are deliberately not stored here, otherwise the next
+				// run would get a 304 and never deliver that post.
+				outcome := fetchOne(ctx, rssClient, fetchJob{
+					request: rss.Request{URL: feed, Headers: f.Headers, PublicOnly: f.FromSlack},
+					timeout: cfg.FetchTimeout(f),
+				})
+				items, err := outcome.result.Items, outcome.err
 				if err != nil {
 					log.Printf("Warning: Failed to fetch new feed %s for initial setup: %v", feed, err)
 					// Fallback to 24 hours ago if we can't fetch the feed
@@ -111,11 +264,11 @@ func updateSubscriptions(cfg config.Config, state *st.State, rssClient RSSClient
 				}
 			}
 		}
-		// Remove feeds not in config
+		// Remove feeds not in config. Disabled feeds keep their state.
 		for feed := range channelState.Feeds {
 			found := false
 			for _, f := range ch.Feeds {
-				if f == feed {
+				if f.URL == feed {
 					found = true
 					break
 				}
@@ -129,50 +282,483 @@ func updateSubscriptions(cfg config.Config, state *st.State, rssClient RSSClient
 	}
 }
 
-func processFeeds(cfg config.Config, state *st.State, slackClient SlackClient, rssClient RSSClient) (int, int) {
+// processFeeds fetches every feed and posts new items. If ctx is cancelled it
+// stops before the next feed, leaving the remaining feeds' state untouched so
+// their items are picked up on the next run.
+func processFeeds(ctx context.Context, cfg config.Config, state *st.State, slackClient SlackClient, rssClient RSSClient) (int, int) {
 	totalFeeds := 0
 	totalNewPosts := 0
+	now := time.Now()
 
+	retryPending(ctx, cfg, state, slackClient)
+
+	// Pick the feeds to check this run and fetch them all up front in
+	// parallel, then post and update state in config order on this goroutine.
+	type feedTask struct {
+		ch   config.Channel
+		feed config.Feed
+	}
+	var tasks []feedTask
+	var jobs []fetchJob
 	for _, ch := range cfg.Channels {
-		channel := ch.SlackChannel
+		chState := state.Channels[ch.SlackChannel]
+		for _, feed := range ch.Feeds {
+			feedState := chState.Feeds[feed.URL]
+			if !feed.IsEnabled() {
+				log.Printf("Skipping disabled feed: %s", feed.URL)
+				continue
+			}
+			if !feedState.Due(now) {
+				log.Printf("Skipping feed %s, not due until %s", feed.URL, feedState.NextDue.Format(time.RFC3339))
+				continue
+			}
+			tasks = append(tasks, feedTask{ch: ch, feed: feed})
+			jobs = append(jobs, fetchJob{
+				request: rss.Request{
+					URL:        feed.URL,
+					Headers:    feed.Headers,
+					Cache:      rss.CacheInfo{ETag: feedState.ETag, LastModified: feedState.LastModified},
+					PublicOnly: feed.FromSlack,
+				},
+				timeout: cfg.FetchTimeout(feed),
+			})
+		}
+	}
+	concurrency, perHost := cfg.FetchLimits()
+	log.Printf("Fetching %d feeds (concurrency %d, per host %d)", len(jobs), concurrency, perHost)
+	fetchCtx := ctx
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	outcomes := fetchAll(fetchCtx, jobs, rssClient, concurrency, perHost)
+	// Checked once here, since the run timeout may pass while posting below
+	runStopped := fetchCtx.Err() != nil
+
+	for i, task := range tasks {
+		ch, feed := task.ch, task.feed
+		channel, feedURL := ch.SlackChannel, feed.URL
+		if i == 0 || tasks[i-1].ch.SlackChannel != channel {
+			log.Printf("Processing channel: %s", channel)
+		}
+		if ctx.Err() != nil {
+			log.Printf("Stopping before %s: %v", feedURL, ctx.Err())
+			return totalFeeds, totalNewPosts
+		}
+		totalFeeds++
 		chState := state.Channels[channel]
-		log.Printf("Processing channel: %s", channel)
+		log.Printf("Checking feed: %s", feedURL)
+		feedState := chState.Feeds[feedURL]
+		lastUpdated := feedState.LastUpdated
+		log.Printf("Last updated: %s", lastUpdated.Format(time.RFC3339))
 
-		for _, feedURL := range ch.Feeds {
-			totalFeeds++
-			log.Printf("Checking feed: %s", feedURL)
-			lastUpdated := chState.Feeds[feedURL].LastUpdated
-			log.Printf("Last updated: %s", lastUpdated.Format(time.RFC3339))
+		result, err := outcomes[i].result, outcomes[i].err
+		// Fetches cut short by the run's own timeout say nothing about the feed
+		if err != nil && runStopped && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+			log.Printf("Fetching feed %s stopped by the run timeout: %v", feedURL, err)
+			continue
+		}
+		recordFetch(cfg, &feedState, slackClient, channel, feedURL, outcomes[i])
+		if err != nil {
+			log.Printf("Error fetching feed %s: %v", feedURL, err)
+			chState.Feeds[feedURL] = feedState
+			continue
+		}
+		if result.NotModified {
+			log.Printf("Feed %s not modified since last fetch", feedURL)
+			scheduleNext(&feedState, feed, nil, now)
+			chState.Feeds[feedURL] = feedState
+			continue
+		}
+
+		if feed.Name != "" {
+			for i := range result.Items {
+				result.Items[i].FeedTitle = feed.Name
+			}
+		}
+		items := newItems(result.Items, feedState)
 
-			items, newLastUpdated, err := rssClient.FetchFeed(feedURL, lastUpdated)
+		log.Printf("Found %d new items in feed %s", len(items), feedURL)
+		items = applyFilters(items, ch, feed)
+		totalNewPosts += len(items)
+
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Published.Before(items[j].Published)
+		})
+
+		notifier := notifierFor(cfg, ch, feed, slackClient)
+		// Several items from one run can be posted as a thread up front
+		threader, threaded := notifier.(notify.ThreadNotifier)
+		threaded = threaded && ch.Thread != "" && len(items) > 1
+		var threadPosts map[string]notify.Posted
+		var threadErrs map[string]error
+		if threaded {
+			threadPosts, threadErrs = postThread(threader, channel, ch.Thread, items)
+		}
+		failed := make(map[string]bool)
+		for _, item := range items {
+			if ch.Batched() {
+				// Sent together with the channel's other new items by deliverGroups
+				feedState.Hold(item)
+				failed[item.ID] = true
+				continue
+			}
+			msg, err := threadPosts[item.ID], threadErrs[item.ID]
+			if !threaded {
+				msg, err = postItem(notifier, channel, item)
+			}
 			if err != nil {
-				log.Printf("Error fetching feed %s: %v", feedURL, err)
+				feedState.AddPending(item, err)
+				failed[item.ID] = true
 				continue
 			}
+			feedState.RecordDelivery(delivery(item, msg))
+		}
+		if editor, ok := notifier.(notify.Editor); ok && ch.Edits != "" {
+			followEdits(editor, channel, ch.Edits, &feedState, result.Items)
+		}
 
-			log.Printf("Found %d new items in feed %s", len(items), feedURL)
-			totalNewPosts += len(items)
+		// The cursor only moves past items that were delivered; failed ones
+		// are kept as pending and retried at the start of the next run
+		newLastUpdated := lastUpdated
+		for _, item := range result.Items {
+			if !failed[item.ID] && item.Published.After(newLastUpdated) {
+				newLastUpdated = item.Published
+			}
+		}
+		if !newLastUpdated.Equal(lastUpdated) {
+			log.Printf("Updating last updated time for %s to %s", feedURL, newLastUpdated.Format(time.RFC3339))
+			feedState.LastUpdated = newLastUpdated
+		}
+		ids := make([]string, len(result.Items))
+		for i, item := range result.Items {
+			ids[i] = item.ID
+		}
+		feedState.MarkSeen(ids)
+		feedState.ETag = result.Cache.ETag
+		feedState.LastModified = result.Cache.LastModified
+		scheduleNext(&feedState, feed, result.Items, now)
+		chState.Feeds[feedURL] = feedState
+		state.Channels[channel] = chState
+	}
 
-			sort.Slice(items, func(i, j int) bool {
-				return items[i].Published.Before(items[j].Published)
-			})
+	deliverGroups(ctx, cfg, state, slackClient, now)
+	return totalFeeds, totalNewPosts
+}
 
-			for _, item := range items {
-				log.Printf("Posting new item to #%s: %s", channel, item.Title)
-				if err := slackClient.PostMessage("#"+channel, rss.FormatItem(item)); err != nil {
-					log.Printf("Error posting to Slack: %v", err)
-				} else {
-					log.Printf("Successfully posted to #%s", channel)
+// newItems picks the items to post. Once a feed has a record of seen item IDs
+// that record decides what is new, so backdated and undated items are posted
+// exactly once. Feeds without one yet (newly added, or carried over from
+// timestamp-only state) fall back to comparing dates against LastUpdated;
+// their undated items are only recorded.
+func newItems(all []rss.FeedItem, feedState st.FeedState) []rss.FeedItem {
+	seen := feedState.Seen()
+	seeded := len(seen) > 0
+
+	var items []rss.FeedItem
+	for _, item := range all {
+		if seen[item.ID] {
+			continue
+		}
+		if seeded || (!item.Published.IsZero() && item.Published.After(feedState.LastUpdated)) {
+			items = append(items, item)
+		}
+		seen[item.ID] = true
+	}
+	return items
+}
+
+// maxDeliveryAttempts is how many times a pending item is tried in total
+// before it is dropped.
+const maxDeliveryAttempts = 5
+
+// retryPending posts the items that could not be delivered on earlier runs,
+// before anything new is fetched. Items that fail again stay pending until
+// they run out of attempts.
+func retryPending(ctx context.Context, cfg config.Config, state *st.State, slackClient SlackClient) {
+	for _, ch := range cfg.Channels {
+		// Batched channels retry with their next batch
+		if ch.Batched() {
+			continue
+		}
+		chState := state.Channels[ch.SlackChannel]
+		for _, feed := range ch.Feeds {
+			feedState, ok := chState.Feeds[feed.URL]
+			if !ok || len(feedState.Pending) == 0 || !feed.IsEnabled() {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Retrying %d undelivered items from %s", len(feedState.Pending), feed.URL)
+			notifier := notifierFor(cfg, ch, feed, slackClient)
+			pending := feedState.Pending
+			feedState.Pending = nil
+			for _, p := range pending {
+				msg, err := postItem(notifier, ch.SlackChannel, p.Item)
+				settlePending(&feedState, p, msg, err)
+			}
+			chState.Feeds[feed.URL] = feedState
+		}
+	}
+}
+
+// deliverGroups sends the items held for each batched channel in a single
+// message once all feeds are checked: grouped channels after every run, digest
+// channels when their schedule is due. If that fails the items stay pending
+// and go out with the next batch, until they run out of attempts.
+func deliverGroups(ctx context.Context, cfg config.Config, state *st.State, slackClient SlackClient, now time.Time) {
+	for _, ch := range cfg.Channels {
+		if !ch.Batched() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		chState := state.Channels[ch.SlackChannel]
+		digest := !ch.Digest.IsZero()
+		if digest {
+			// The schedule starts with the first run that knows about it
+			if chState.LastDigest.IsZero() {
+				chState.LastDigest = now
+				state.Channels[ch.SlackChannel] = chState
+			}
+			if now.Before(ch.Digest.Next(chState.LastDigest)) {
+				continue
+			}
+		}
+
+		var items []rss.FeedItem
+		for _, feed := range ch.Feeds {
+			if feed.IsEnabled() {
+				for _, p := range chState.Feeds[feed.URL].Pending {
+					items = append(items, p.Item)
 				}
 			}
+		}
+		if len(items) == 0 {
+			if digest {
+				log.Printf("No new items for the digest of #%s", ch.SlackChannel)
+				chState.LastDigest = now
+				state.Channels[ch.SlackChannel] = chState
+			}
+			continue
+		}
 
-			if !newLastUpdated.Equal(lastUpdated) {
-				log.Printf("Updating last updated time for %s to %s", feedURL, newLastUpdated.Format(time.RFC3339))
-				chState.Feeds[feedURL] = st.FeedState{LastUpdated: newLastUpdated}
-				state.Channels[channel] = chState
+		// Only targets that can batch are allowed, which was validated when the config was loaded
+		notifier := notifierFor(cfg, ch, config.Feed{}, slackClient).(notify.BatchNotifier)
+		log.Printf("Sending %d items to #%s", len(items), ch.SlackChannel)
+		err := notifier.NotifyAll(items)
+		if err != nil {
+			log.Printf("Error sending to #%s: %v", ch.SlackChannel, err)
+		} else {
+			log.Printf("Successfully sent to #%s", ch.SlackChannel)
+			if digest {
+				chState.LastDigest = now
+			}
+		}
+		for _, feed := range ch.Feeds {
+			feedState, ok := chState.Feeds[feed.URL]
+			if !ok || len(feedState.Pending) == 0 || !feed.IsEnabled() {
+				continue
 			}
+			pending := feedState.Pending
+			feedState.Pending = nil
+			for _, p := range pending {
+				settlePending(&feedState, p, notify.Posted{}, err)
+			}
+			chState.Feeds[feed.URL] = feedState
 		}
+		state.Channels[ch.SlackChannel] = chState
 	}
+}
 
-	return totalFeeds, totalNewPosts
+// settlePending records the outcome of an attempt to deliver a pending item:
+// delivered as msg, kept for another attempt, or dropped after
+// maxDeliveryAttempts.
+func settlePending(feedState *st.FeedState, p st.PendingItem, msg notify.Posted, err error) {
+	if err != nil {
+		p.Attempts++
+		p.LastError = err.Error()
+		if p.Attempts >= maxDeliveryAttempts {
+			log.Printf("Giving up on %s after %d attempts", p.Item.Link, p.Attempts)
+			return
+		}
+		feedState.Pending = append(feedState.Pending, p)
+		return
+	}
+	feedState.RecordDelivery(delivery(p.Item, msg))
+	if p.Item.Published.After(feedState.LastUpdated) {
+		feedState.LastUpdated = p.Item.Published
+	}
+}
+
+// feedTemplate returns the parsed message template for a feed, or nil when
+// the default message is used.
+func feedTemplate(ch config.Channel, feed config.Feed) *render.Template {
+	text := ch.TemplateFor(feed)
+	if text == "" {
+		return nil
+	}
+	// Already validated when the config was loaded
+	tmpl, _ := render.Parse(feed.URL, text)
+	return tmpl
+}
+
+// webhookClient sends the requests of webhook targets.
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// notifierFor returns the notifier delivering a feed's items to the channel's
+// target. When slackClient can send HTTP requests or mail itself, webhooks and
+// email go through it too; that is how dry runs print them instead.
+func notifierFor(cfg config.Config, ch config.Channel, feed config.Feed, slackClient SlackClient) notify.Notifier {
+	var client notify.Doer = webhookClient
+	if doer, ok := slackClient.(notify.Doer); ok {
+		client = doer
+	}
+	var mailer notify.Mailer = cfg.SMTP.Mailer()
+	if m, ok := slackClient.(notify.Mailer); ok {
+		mailer = m
+	}
+	maxItems := 0
+	if !ch.Digest.IsZero() {
+		maxItems = ch.Digest.Limit()
+	}
+	// Already validated when the config was loaded
+	notifier, _ := notify.New(notify.Target{
+		Type:     ch.Target.Type,
+		Channel:  ch.SlackChannel,
+		URL:      ch.Target.WebhookURL(),
+		Template: feedTemplate(ch, feed),
+		Mailer:   mailer,
+		From:     cfg.SMTP.From,
+		To:       ch.Target.To,
+		MaxItems: maxItems,
+	}, slackClient, client)
+	return notifier
+}
+
+// postItem delivers a single item to channel, and returns the message it
+// posted when the notifier tells.
+func postItem(notifier notify.Notifier, channel string, item rss.FeedItem) (notify.Posted, error) {
+	log.Printf("Posting new item to #%s: %s", channel, item.Title)
+	var msg notify.Posted
+	var err error
+	if editor, ok := notifier.(notify.Editor); ok {
+		msg, err = editor.Post(item)
+	} else {
+		err = notifier.Notify(item)
+	}
+	if err != nil {
+		log.Printf("Error posting to #%s: %v", channel, err)
+		return notify.Posted{}, err
+	}
+	log.Printf("Successfully posted to #%s", channel)
+	return msg, nil
+}
+
+// delivery records item as delivered now in msg, which is empty for targets
+// other than the Slack bot.
+func delivery(item rss.FeedItem, msg notify.Posted) st.Delivery {
+	d := st.NewDelivery(item, time.Now())
+	d.ChannelID, d.TS, d.Thread = msg.Channel, msg.TS, msg.Thread
+	return d
+}
+
+// postThread delivers a feed's items from one run as a thread, started by a
+// summary or by the first item that gets through. The messages of the items
+// posted are returned in posted and the errors of the others in failed; if the
+// thread can't be started the items are posted on their own.
+func postThread(notifier notify.ThreadNotifier, channel, mode string, items []rss.FeedItem) (map[string]notify.Posted, map[string]error) {
+	posted := make(map[string]notify.Posted)
+	failed := make(map[string]error)
+	thread := ""
+	if mode == config.ThreadSummary {
+		ts, err := notifier.StartThread(items)
+		if err != nil {
+			log.Printf("Error starting thread in #%s, posting items on their own: %v", channel, err)
+		}
+		thread = ts
+	}
+	for _, item := range items {
+		log.Printf("Posting new item to #%s: %s", channel, item.Title)
+		msg, err := notifier.NotifyThread(item, thread)
+		if err != nil {
+			log.Printf("Error posting to #%s: %v", channel, err)
+			failed[item.ID] = err
+			continue
+		}
+		log.Printf("Successfully posted to #%s", channel)
+		posted[item.ID] = msg
+		if thread == "" && mode == config.ThreadFirst {
+			thread = msg.TS
+		}
+	}
+	return posted, failed
+}
+
+// followEdits catches up on items that changed since they were posted: their
+// message is updated, or a reply in its thread notes the change, as mode says.
+// Only the deliveries still in the state are followed. Each edit is tried
+// once, and the new content recorded either way, so that a message deleted
+// from Slack isn't retried on every run.
+func followEdits(editor notify.Editor, channel, mode string, feedState *st.FeedState, items []rss.FeedItem) {
+	latest := make(map[string]int, len(feedState.Delivered))
+	for i, d := range feedState.Delivered {
+		latest[d.ItemID] = i
+	}
+	for _, item := range items {
+		i, ok := latest[item.ID]
+		if !ok {
+			continue
+		}
+		d := &feedState.Delivered[i]
+		hash := st.ContentHash(item)
+		if d.Hash == hash {
+			continue
+		}
+		// Deliveries recorded before hashes were kept have nothing to compare
+		// against, and those of other targets no message to follow up on
+		if d.Hash != "" && d.TS != "" {
+			msg := notify.Posted{Channel: d.ChannelID, TS: d.TS, Thread: d.Thread}
+			var err error
+			switch {
+			case mode == config.EditsUpdate:
+				log.Printf("Updating edited item in #%s: %s", channel, item.Title)
+				err = editor.Update(msg, item)
+			// Replies are only worth it for a new title or link, not for
+			// summaries that change on every fetch, e.g. with comment counts
+			case item.Title != d.Title || item.Link != d.Link:
+				log.Printf("Noting edited item in #%s: %s", channel, item.Title)
+				err = editor.Annotate(msg, rss.FeedItem{Title: d.Title, Link: d.Link}, item)
+			}
+			if err != nil {
+				log.Printf("Error following up on %s in #%s: %v", item.Link, channel, err)
+			}
+		}
+		d.Title, d.Link, d.Hash = item.Title, item.Link, hash
+	}
+}
+
+// applyFilters drops items rejected by the channel's or the feed's filter
+// rules and logs how many items each rule dropped.
+func applyFilters(items []rss.FeedItem, ch config.Channel, feed config.Feed) []rss.FeedItem {
+	if ch.Filters.IsZero() && feed.Filters.IsZero() {
+		return items
+	}
+	// Both were validated when the config was loaded
+	channelRules, _ := ch.Filters.Compile()
+	feedRules, _ := feed.Filters.Compile()
+	kept, dropped := filter.Apply(items, channelRules, feedRules)
+
+	rules := make([]string, 0, len(dropped))
+	for rule := range dropped {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		log.Printf("Filter %q dropped %d items from %s", rule, dropped[rule], feed.URL)
+	}
+	return kept
 }
@@ -1,60 +1,106 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/notify"
 	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
 	"slack-rss-feed-manager/state"
 )
 
 type mockSlackClient struct {
 	messages []struct {
-		channel string
-		text    string
+		channel  string
+		text     string
+		threadTS string
 	}
+	// updates holds the messages updated, by timestamp
+	updates map[string]string
+	// err, when set, fails every post
+	err error
 }
 
-func (m *mockSlackClient) PostMessage(channel, text string) error {
+// PostMessage records the message and returns the channel as its ID and the
+// message's position, counting from 1, as its timestamp.
+func (m *mockSlackClient) PostMessage(channel string, msg slack.Message) (string, string, error) {
+	if m.err != nil {
+		return "", "", m.err
+	}
 	if m.messages == nil {
 		m.messages = make([]struct {
-			channel string
-			text    string
+			channel  string
+			text     string
+			threadTS string
 		}, 0)
 	}
 	m.messages = append(m.messages, struct {
-		channel string
-		text    string
-	}{channel, text})
+		channel  string
+		text     string
+		threadTS string
+	}{channel, msg.Text, msg.ThreadTS})
+	return channel, strconv.Itoa(len(m.messages)), nil
+}
+
+func (m *mockSlackClient) UpdateMessage(channelID, ts string, msg slack.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.updates == nil {
+		m.updates = make(map[string]string)
+	}
+	m.updates[ts] = msg.Text
 	return nil
 }
 
 type mockRSSClient struct {
-	items []rss.FeedItem
-	err   error
+	items       []rss.FeedItem
+	err         error
+	cache       rss.CacheInfo
+	notModified bool
+
+	mu            sync.Mutex
+	gotCache      rss.CacheInfo
+	gotHeaders    map[string]string
+	gotPublicOnly bool
+	fetched       []string
 }
 
-func (m *mockRSSClient) FetchFeed(url string, lastUpdated time.Time) ([]rss.FeedItem, time.Time, error) {
+func (m *mockRSSClient) FetchFeed(ctx context.Context, req rss.Request) (rss.FetchResult, error) {
+	cache := req.Cache
+	m.mu.Lock()
+	m.gotCache = cache
+	m.gotHeaders = req.Headers
+	m.gotPublicOnly = req.PublicOnly
+	m.fetched = append(m.fetched, req.URL)
+	m.mu.Unlock()
 	if m.err != nil {
-		return nil, time.Time{}, m.err
+		return rss.FetchResult{Cache: cache}, m.err
 	}
-	
-	var latest time.Time
-	var filteredItems []rss.FeedItem
-	
-	for _, item := range m.items {
-		if item.Published.After(latest) {
-			latest = item.Published
-		}
-		// Only return items newer than lastUpdated (like the real implementation)
-		if item.Published.After(lastUpdated) {
-			filteredItems = append(filteredItems, item)
+	if m.notModified {
+		return rss.FetchResult{Cache: cache, NotModified: true}, nil
+	}
+	// Like the real implementation, return everything and let processFeeds decide what is new
+	items := make([]rss.FeedItem, len(m.items))
+	for i, item := range m.items {
+		if item.ID == "" {
+			item.ID = item.Link
 		}
+		items[i] = item
 	}
-	
-	return filteredItems, latest, nil
+	return rss.FetchResult{Items: items, Cache: m.cache}, nil
 }
 
 func TestUpdateSubscriptions(t *testing.T) {
@@ -71,7 +117,7 @@ func TestUpdateSubscriptions(t *testing.T) {
 				Channels: []config.Channel{
 					{
 						SlackChannel: "test-channel",
-						Feeds:        []string{"http://example.com/feed1"},
+						Feeds:        []config.Feed{{URL: "http://example.com/feed1"}},
 					},
 				},
 			},
@@ -87,7 +133,7 @@ func TestUpdateSubscriptions(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			currentState := tt.initialState
 			mockRSS := &mockRSSClient{}
-			updateSubscriptions(tt.config, &currentState, mockRSS)
+			updateSubscriptions(context.Background(), tt.config, &currentState, mockRSS)
 
 			// Verify channel exists
 			channelState, exists := currentState.Channels[tt.expectedChannel]
@@ -136,7 +182,7 @@ func TestNewFeedDeliversMostRecentPost(t *testing.T) {
 		Channels: []config.Channel{
 			{
 				SlackChannel: "test-channel",
-				Feeds:        []string{"http://example.com/feed"},
+				Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
 			},
 		},
 	}
@@ -145,7 +191,7 @@ func TestNewFeedDeliversMostRecentPost(t *testing.T) {
 		Channels: make(map[string]state.ChannelState),
 	}
 	
-	updateSubscriptions(cfg, &currentState, mockRSS)
+	updateSubscriptions(context.Background(), cfg, &currentState, mockRSS)
 	
 	feedState := currentState.Channels["test-channel"].Feeds["http://example.com/feed"]
 	expectedLastUpdated := mostRecentTime.Add(-1 * time.Hour)
@@ -155,6 +201,32 @@ func TestNewFeedDeliversMostRecentPost(t *testing.T) {
 	}
 }
 
+func TestNewUndatedFeedPostsNothing(t *testing.T) {
+	var items []rss.FeedItem
+	for i := 0; i < 20; i++ {
+		items = append(items, rss.FeedItem{ID: fmt.Sprint(i), Title: fmt.Sprintf("Post %d", i), Link: fmt.Sprintf("http://example.com/%d", i)})
+	}
+	mockRSS := &mockRSSClient{items: items}
+	cfg := config.Config{Channels: []config.Channel{
+		{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: "http://example.com/feed"}}},
+	}}
+	currentState := state.State{Channels: make(map[string]state.ChannelState)}
+
+	updateSubscriptions(context.Background(), cfg, &currentState, mockRSS)
+	mockSlack := &mockSlackClient{}
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 0 {
+		t.Errorf("Expected the undated backlog to be recorded only, got %d messages", len(mockSlack.messages))
+	}
+
+	// Items appearing later are new even without dates
+	mockRSS.items = append(items, rss.FeedItem{ID: "new", Title: "New Post", Link: "http://example.com/new"})
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 1 || !strings.Contains(mockSlack.messages[0].text, "New Post") {
+		t.Errorf("Expected only the new item to be posted, got %+v", mockSlack.messages)
+	}
+}
+
 func TestProcessFeeds(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
@@ -172,7 +244,7 @@ func TestProcessFeeds(t *testing.T) {
 				Channels: []config.Channel{
 					{
 						SlackChannel: "test-channel",
-						Feeds:        []string{"http://example.com/feed"},
+						Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
 					},
 				},
 			},
@@ -217,7 +289,7 @@ func TestProcessFeeds(t *testing.T) {
 			mockSlack := &mockSlackClient{}
 			mockRSS := &mockRSSClient{items: tt.mockFeedItems}
 
-			feedsProcessed, postsFound := processFeeds(tt.config, &currentState, mockSlack, mockRSS)
+			feedsProcessed, postsFound := processFeeds(context.Background(), tt.config, &currentState, mockSlack, mockRSS)
 
 			if feedsProcessed != 1 {
 				t.Errorf("expected 1 feed processed, got %d", feedsProcessed)
@@ -248,6 +320,1009 @@ func TestProcessFeeds(t *testing.T) {
 	}
 }
 
+func TestProcessFeedsSeenItems(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "test-channel",
+				Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
+			},
+		},
+	}
+	newState := func(seen ...string) state.State {
+		return state.State{
+			Channels: map[string]state.ChannelState{
+				"test-channel": {
+					Feeds: map[string]state.FeedState{
+						"http://example.com/feed": {LastUpdated: lastUpdated, SeenIDs: seen},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		seen          []string
+		items         []rss.FeedItem
+		expectedPosts []string
+	}{
+		{
+			name: "skips seen items even when their date changes",
+			seen: []string{"a"},
+			items: []rss.FeedItem{
+				{ID: "a", Title: "Edited Post", Published: lastUpdated.Add(2 * time.Hour)},
+				{ID: "b", Title: "New Post", Published: lastUpdated.Add(time.Hour)},
+			},
+			expectedPosts: []string{"New Post"},
+		},
+		{
+			name: "posts backdated items that were not seen",
+			seen: []string{"a"},
+			items: []rss.FeedItem{
+				{ID: "a", Title: "Old Post", Published: lastUpdated.Add(-2 * time.Hour)},
+				{ID: "b", Title: "Backdated Post", Published: lastUpdated.Add(-time.Hour)},
+			},
+			expectedPosts: []string{"Backdated Post"},
+		},
+		{
+			name: "posts undated items once seen IDs are recorded",
+			seen: []string{"a"},
+			items: []rss.FeedItem{
+				{ID: "a", Title: "Old Post", Published: lastUpdated.Add(-2 * time.Hour)},
+				{ID: "b", Title: "Undated Post"},
+			},
+			expectedPosts: []string{"Undated Post"},
+		},
+		{
+			name: "falls back to dates without seen IDs",
+			items: []rss.FeedItem{
+				{ID: "a", Title: "Old Post", Published: lastUpdated.Add(-2 * time.Hour)},
+				{ID: "b", Title: "Undated Post"},
+				{ID: "c", Title: "New Post", Published: lastUpdated.Add(time.Hour)},
+			},
+			expectedPosts: []string{"New Post"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentState := newState(tt.seen...)
+			mockSlack := &mockSlackClient{}
+			mockRSS := &mockRSSClient{items: tt.items}
+
+			processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+			if len(mockSlack.messages) != len(tt.expectedPosts) {
+				t.Fatalf("expected %d messages, got %d", len(tt.expectedPosts), len(mockSlack.messages))
+			}
+			for i, title := range tt.expectedPosts {
+				if !contains(mockSlack.messages[i].text, title) {
+					t.Errorf("message %d = %q, expected to contain %q", i, mockSlack.messages[i].text, title)
+				}
+			}
+
+			// A second run over the same items posts nothing
+			mockSlack = &mockSlackClient{}
+			processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+			if len(mockSlack.messages) != 0 {
+				t.Errorf("expected no messages on second run, got %d", len(mockSlack.messages))
+			}
+		})
+	}
+}
+
+func TestProcessFeedsDeliveryFailures(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feedURL := "http://example.com/feed"
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: feedURL}}},
+		},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {
+				Feeds: map[string]state.FeedState{
+					feedURL: {LastUpdated: lastUpdated},
+				},
+			},
+		},
+	}
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{
+		{Title: "New Post", Link: "http://example.com/new", Published: lastUpdated.Add(time.Hour)},
+	}}
+	feedState := func() state.FeedState {
+		return currentState.Channels["test-channel"].Feeds[feedURL]
+	}
+
+	// Posting fails: the cursor stays put and the item is kept for later
+	processFeeds(context.Background(), cfg, &currentState, &mockSlackClient{err: errors.New("slack down")}, mockRSS)
+	if !feedState().LastUpdated.Equal(lastUpdated) {
+		t.Errorf("LastUpdated advanced to %v despite failed post", feedState().LastUpdated)
+	}
+	pending := feedState().Pending
+	if len(pending) != 1 || pending[0].Item.Title != "New Post" || pending[0].Attempts != 1 || pending[0].LastError != "slack down" {
+		t.Fatalf("Expected failed item to be pending, got %+v", pending)
+	}
+
+	// The next run delivers it first, without posting it a second time from the feed
+	mockSlack := &mockSlackClient{}
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 1 || !contains(mockSlack.messages[0].text, "New Post") {
+		t.Fatalf("Expected pending item to be posted once, got %+v", mockSlack.messages)
+	}
+	if len(feedState().Pending) != 0 {
+		t.Errorf("Expected no pending items, got %+v", feedState().Pending)
+	}
+	if delivered := feedState().Delivered; len(delivered) != 1 || delivered[0].Title != "New Post" {
+		t.Errorf("Expected the delivery to be recorded, got %+v", delivered)
+	}
+	if !feedState().LastUpdated.Equal(lastUpdated.Add(time.Hour)) {
+		t.Errorf("LastUpdated = %v, expected it to move past the delivered item", feedState().LastUpdated)
+	}
+}
+
+func TestProcessFeedsWebhookTarget(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feedURL := "http://example.com/feed"
+	var mu sync.Mutex
+	var titles []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body notify.WebhookItem
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid webhook body: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if status == http.StatusOK {
+			titles = append(titles, body.Title)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	cfg := config.Config{
+		Channels: []config.Channel{{
+			SlackChannel: "test-channel",
+			Target:       config.Target{Type: notify.Webhook, URL: server.URL},
+			Feeds:        []config.Feed{{URL: feedURL}},
+		}},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {Feeds: map[string]state.FeedState{feedURL: {LastUpdated: lastUpdated}}},
+		},
+	}
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{
+		{ID: "a", Title: "New Post", Link: "http://example.com/new", Published: lastUpdated.Add(time.Hour)},
+	}}
+	feedState := func() state.FeedState {
+		return currentState.Channels["test-channel"].Feeds[feedURL]
+	}
+
+	// A failing webhook keeps the item pending, like a failed Slack post
+	status = http.StatusBadGateway
+	mockSlack := &mockSlackClient{}
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if pending := feedState().Pending; len(pending) != 1 || !strings.Contains(pending[0].LastError, "502") {
+		t.Fatalf("Expected the item to be pending after HTTP 502, got %+v", pending)
+	}
+
+	status = http.StatusOK
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(titles) != 1 || titles[0] != "New Post" {
+		t.Errorf("Expected the item to be sent to the webhook once, got %q", titles)
+	}
+	if len(mockSlack.messages) != 0 {
+		t.Errorf("Expected nothing posted to Slack, got %+v", mockSlack.messages)
+	}
+	if len(feedState().Pending) != 0 || len(feedState().Delivered) != 1 {
+		t.Errorf("Expected the delivery to be recorded, got %+v", feedState())
+	}
+}
+
+func TestWebhookOnlyWithoutSlackToken(t *testing.T) {
+	t.Setenv("SLACK_BOT_TOKEN", "")
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feedURL := "http://example.com/feed"
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	cfg := config.Config{
+		Channels: []config.Channel{{
+			SlackChannel: "test-channel",
+			Target:       config.Target{Type: notify.Webhook, URL: server.URL},
+			Feeds:        []config.Feed{{URL: feedURL}},
+		}},
+	}
+	slackClient, err := newSlackClient(cfg)
+	if err != nil {
+		t.Fatalf("Expected a webhook-only config to need no Slack token, got %v", err)
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {Feeds: map[string]state.FeedState{feedURL: {LastUpdated: lastUpdated}}},
+		},
+	}
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{
+		{ID: "a", Title: "New Post", Link: "http://example.com/new", Published: lastUpdated.Add(time.Hour)},
+	}}
+	processFeeds(context.Background(), cfg, &currentState, slackClient, mockRSS)
+	if received != 1 || len(currentState.Channels["test-channel"].Feeds[feedURL].Pending) != 0 {
+		t.Errorf("Expected the item to be sent to the webhook, got %d requests", received)
+	}
+
+	// Posting through the Slack bot, or alerts, still needs the token
+	cfg.Alerts.Channel = "rss-admin"
+	if _, err := newSlackClient(cfg); err == nil {
+		t.Error("Expected an error without a token when alerts go to Slack")
+	}
+}
+
+// mockMailer records emails. It also stands in for Slack, which is how
+// notifierFor picks it up as the mailer.
+type mockMailer struct {
+	mockSlackClient
+	sent []string
+	err  error
+}
+
+func (m *mockMailer) SendMail(from string, to []string, msg []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, string(msg))
+	return nil
+}
+
+func TestProcessFeedsGroupedEmail(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		SMTP: config.SMTP{Host: "smtp.example.com", From: "rss@example.com"},
+		Channels: []config.Channel{{
+			SlackChannel: "digest",
+			Target:       config.Target{Type: notify.Email, To: []string{"team@example.com"}, Group: true},
+			Feeds:        []config.Feed{{URL: "http://example.com/a"}, {URL: "http://example.com/b"}},
+		}},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"digest": {Feeds: map[string]state.FeedState{
+				"http://example.com/a": {LastUpdated: lastUpdated},
+				"http://example.com/b": {LastUpdated: lastUpdated},
+			}},
+		},
+	}
+	item := func(id string, hours int) rss.FeedItem {
+		return rss.FeedItem{ID: id, Title: "Post " + id, Link: "http://example.com/" + id, Published: lastUpdated.Add(time.Duration(hours) * time.Hour)}
+	}
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{item("1", 1)}}
+	feedState := func(url string) state.FeedState {
+		return currentState.Channels["digest"].Feeds[url]
+	}
+
+	// Sending fails: both feeds' items stay pending
+	processFeeds(context.Background(), cfg, &currentState, &mockMailer{err: errors.New("connection refused")}, mockRSS)
+	for _, url := range []string{"http://example.com/a", "http://example.com/b"} {
+		pending := feedState(url).Pending
+		if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "connection refused" {
+			t.Fatalf("Expected one failed pending item for %s, got %+v", url, pending)
+		}
+		if !feedState(url).LastUpdated.Equal(lastUpdated) {
+			t.Errorf("LastUpdated advanced for %s despite failed email", url)
+		}
+	}
+
+	// The next run sends them in one email together with the new item
+	mockRSS.items = append(mockRSS.items, item("2", 2))
+	mailer := &mockMailer{}
+	processFeeds(context.Background(), cfg, &currentState, mailer, mockRSS)
+	if len(mailer.sent) != 1 {
+		t.Fatalf("Expected one email, got %d", len(mailer.sent))
+	}
+	if n := strings.Count(mailer.sent[0], "Post 1"); n < 2 || !strings.Contains(mailer.sent[0], "Post 2") {
+		t.Errorf("Expected all four items in the email:\n%s", mailer.sent[0])
+	}
+	if len(mailer.messages) != 0 {
+		t.Errorf("Expected nothing posted to Slack, got %+v", mailer.messages)
+	}
+	for _, url := range []string{"http://example.com/a", "http://example.com/b"} {
+		fs := feedState(url)
+		if len(fs.Pending) != 0 || len(fs.Delivered) != 2 {
+			t.Errorf("Expected both items delivered for %s, got %+v", url, fs)
+		}
+		if !fs.LastUpdated.Equal(lastUpdated.Add(2 * time.Hour)) {
+			t.Errorf("LastUpdated = %v for %s, expected the newest delivered item", fs.LastUpdated, url)
+		}
+	}
+
+	// Nothing new, nothing sent
+	processFeeds(context.Background(), cfg, &currentState, mailer, mockRSS)
+	if len(mailer.sent) != 1 {
+		t.Errorf("Expected no email without new items, got %d", len(mailer.sent))
+	}
+}
+
+func TestProcessFeedsDigest(t *testing.T) {
+	lastUpdated := time.Now().Add(-3 * time.Hour)
+	feedURL := "http://example.com/feed"
+	cfg := config.Config{
+		Channels: []config.Channel{{
+			SlackChannel: "low-priority",
+			Digest:       config.Digest{Every: "daily", At: "09:00", Timezone: "UTC", MaxItems: 1},
+			Feeds:        []config.Feed{{URL: feedURL}},
+		}},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"low-priority": {Feeds: map[string]state.FeedState{feedURL: {LastUpdated: lastUpdated}}},
+		},
+	}
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{
+		{ID: "1", Title: "Older", Link: "http://example.com/1", FeedTitle: "Blog", Published: lastUpdated.Add(time.Hour)},
+	}}
+	channelState := func() state.ChannelState {
+		return currentState.Channels["low-priority"]
+	}
+
+	// The first run starts the schedule and holds the item
+	mockSlack := &mockSlackClient{}
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 0 {
+		t.Fatalf("Expected nothing posted before the digest is due, got %+v", mockSlack.messages)
+	}
+	if channelState().LastDigest.IsZero() {
+		t.Error("Expected the digest schedule to start")
+	}
+	if pending := channelState().Feeds[feedURL].Pending; len(pending) != 1 || pending[0].Attempts != 0 {
+		t.Fatalf("Expected the item to be held, got %+v", pending)
+	}
+
+	// Once due, everything held goes out in one message capped at max_items
+	mockRSS.items = append(mockRSS.items, rss.FeedItem{ID: "2", Title: "Newer", Link: "http://example.com/2", FeedTitle: "Blog", Published: lastUpdated.Add(2 * time.Hour)})
+	chState := channelState()
+	chState.LastDigest = time.Now().Add(-25 * time.Hour)
+	currentState.Channels["low-priority"] = chState
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 1 {
+		t.Fatalf("Expected one digest, got %+v", mockSlack.messages)
+	}
+	if msg := mockSlack.messages[0]; msg.channel != "#low-priority" || msg.text != "2 new items" {
+		t.Errorf("Unexpected digest %+v", msg)
+	}
+	fs := channelState().Feeds[feedURL]
+	if len(fs.Pending) != 0 || len(fs.Delivered) != 2 {
+		t.Errorf("Expected both items delivered, got %+v", fs)
+	}
+	if time.Since(channelState().LastDigest) > time.Minute {
+		t.Errorf("Expected LastDigest to move to now, got %v", channelState().LastDigest)
+	}
+
+	// Until the next digest, new items are only held
+	mockRSS.items = append(mockRSS.items, rss.FeedItem{ID: "3", Title: "Newest", Link: "http://example.com/3", Published: lastUpdated.Add(150 * time.Minute)})
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+	if len(mockSlack.messages) != 1 || len(channelState().Feeds[feedURL].Pending) != 1 {
+		t.Errorf("Expected the new item to be held, got %d messages and %+v", len(mockSlack.messages), channelState().Feeds[feedURL].Pending)
+	}
+}
+
+func TestProcessFeedsThreads(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feedURL := "http://example.com/feed"
+	var items []rss.FeedItem
+	for i := 1; i <= 3; i++ {
+		items = append(items, rss.FeedItem{
+			ID: fmt.Sprint(i), Title: fmt.Sprintf("Post %d", i), Link: fmt.Sprintf("http://example.com/%d", i),
+			FeedTitle: "Blog", Published: lastUpdated.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	run := func(thread string, items []rss.FeedItem) *mockSlackClient {
+		cfg := config.Config{Channels: []config.Channel{
+			{SlackChannel: "releases", Thread: thread, Feeds: []config.Feed{{URL: feedURL}}},
+		}}
+		s := state.State{Channels: map[string]state.ChannelState{
+			"releases": {Feeds: map[string]state.FeedState{feedURL: {LastUpdated: lastUpdated}}},
+		}}
+		mockSlack := &mockSlackClient{}
+		processFeeds(context.Background(), cfg, &s, mockSlack, &mockRSSClient{items: items})
+		if delivered := s.Channels["releases"].Feeds[feedURL].Delivered; len(delivered) != len(items) {
+			t.Errorf("thread %q: expected %d deliveries, got %d", thread, len(items), len(delivered))
+		}
+		return mockSlack
+	}
+
+	t.Run("first", func(t *testing.T) {
+		msgs := run(config.ThreadFirst, items).messages
+		if len(msgs) != 3 {
+			t.Fatalf("Expected 3 messages, got %d", len(msgs))
+		}
+		if !strings.Contains(msgs[0].text, "Post 1") || msgs[0].threadTS != "" {
+			t.Errorf("Expected the oldest item to start the thread, got %+v", msgs[0])
+		}
+		for _, msg := range msgs[1:] {
+			if msg.threadTS != "1" {
+				t.Errorf("Expected a reply to the first message, got %+v", msg)
+			}
+		}
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		msgs := run(config.ThreadSummary, items).messages
+		if len(msgs) != 4 {
+			t.Fatalf("Expected a summary and 3 replies, got %d messages", len(msgs))
+		}
+		if msgs[0].text != "3 new items from Blog" || msgs[0].threadTS != "" {
+			t.Errorf("Unexpected summary %+v", msgs[0])
+		}
+		for _, msg := range msgs[1:] {
+			if msg.threadTS != "1" {
+				t.Errorf("Expected a reply to the summary, got %+v", msg)
+			}
+		}
+	})
+
+	t.Run("single item", func(t *testing.T) {
+		msgs := run(config.ThreadSummary, items[:1]).messages
+		if len(msgs) != 1 || msgs[0].threadTS != "" {
+			t.Errorf("Expected a single item to be posted on its own, got %+v", msgs)
+		}
+	})
+}
+
+func TestProcessFeedsEdits(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feedURL := "http://example.com/feed"
+	posted := rss.FeedItem{ID: "a", Title: "Draft", Link: "http://example.com/a", Published: lastUpdated}
+	edited := posted
+	edited.Title = "Final"
+	fresh := rss.FeedItem{ID: "b", Title: "New Post", Link: "http://example.com/b", Published: lastUpdated.Add(time.Hour)}
+
+	run := func(mode string, d state.Delivery, items ...rss.FeedItem) (*mockSlackClient, state.FeedState) {
+		cfg := config.Config{Channels: []config.Channel{
+			{SlackChannel: "releases", Edits: mode, Feeds: []config.Feed{{URL: feedURL}}},
+		}}
+		s := state.State{Channels: map[string]state.ChannelState{
+			"releases": {Feeds: map[string]state.FeedState{feedURL: {
+				LastUpdated: lastUpdated,
+				SeenIDs:     []string{"a"},
+				Delivered:   []state.Delivery{d},
+			}}},
+		}}
+		mockSlack := &mockSlackClient{}
+		processFeeds(context.Background(), cfg, &s, mockSlack, &mockRSSClient{items: items})
+		return mockSlack, s.Channels["releases"].Feeds[feedURL]
+	}
+	delivered := state.NewDelivery(posted, lastUpdated)
+	delivered.ChannelID, delivered.TS = "C123", "1721908800.000100"
+
+	t.Run("update", func(t *testing.T) {
+		mockSlack, fs := run(config.EditsUpdate, delivered, edited, fresh)
+		if text := mockSlack.updates[delivered.TS]; !strings.Contains(text, "Final") {
+			t.Errorf("Expected the message to be updated, got %v", mockSlack.updates)
+		}
+		if d := fs.Delivered[0]; d.Title != "Final" || d.Hash != state.ContentHash(edited) || d.TS != delivered.TS {
+			t.Errorf("Expected the edit to be recorded, got %+v", d)
+		}
+		// The new item's message is kept for later edits
+		if d := fs.Delivered[1]; d.ItemID != "b" || d.ChannelID != "#releases" || d.TS != "1" || d.Hash != state.ContentHash(fresh) {
+			t.Errorf("Unexpected delivery of the new item %+v", d)
+		}
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		mockSlack, _ := run(config.EditsReply, delivered, edited)
+		if len(mockSlack.updates) != 0 || len(mockSlack.messages) != 1 {
+			t.Fatalf("Expected a single reply, got %+v and updates %v", mockSlack.messages, mockSlack.updates)
+		}
+		if msg := mockSlack.messages[0]; msg.threadTS != delivered.TS || !strings.Contains(msg.text, `"Draft"`) {
+			t.Errorf("Expected a note about the new title in the thread, got %+v", msg)
+		}
+	})
+
+	t.Run("reply ignores summary changes", func(t *testing.T) {
+		resummarized := posted
+		resummarized.Summary = "42 comments"
+		mockSlack, fs := run(config.EditsReply, delivered, resummarized)
+		if len(mockSlack.updates) != 0 || len(mockSlack.messages) != 0 {
+			t.Errorf("Expected nothing to be sent, got %+v and updates %v", mockSlack.messages, mockSlack.updates)
+		}
+		if fs.Delivered[0].Hash != state.ContentHash(resummarized) {
+			t.Errorf("Expected the new summary to be recorded, got %+v", fs.Delivered[0])
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		mockSlack, _ := run(config.EditsUpdate, delivered, posted)
+		if len(mockSlack.updates) != 0 || len(mockSlack.messages) != 0 {
+			t.Errorf("Expected nothing to be sent, got %+v and updates %v", mockSlack.messages, mockSlack.updates)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mockSlack, fs := run("", delivered, edited)
+		if len(mockSlack.updates) != 0 || fs.Delivered[0].Title != "Draft" {
+			t.Errorf("Expected edits to be ignored, got updates %v and %+v", mockSlack.updates, fs.Delivered[0])
+		}
+	})
+
+	t.Run("recorded before hashes", func(t *testing.T) {
+		old := state.Delivery{ItemID: "a", Title: "Draft", Link: "http://example.com/a", At: lastUpdated}
+		mockSlack, fs := run(config.EditsUpdate, old, edited)
+		if len(mockSlack.updates) != 0 || fs.Delivered[0].Hash != state.ContentHash(edited) {
+			t.Errorf("Expected only the hash to be recorded, got updates %v and %+v", mockSlack.updates, fs.Delivered[0])
+		}
+	})
+}
+
+func TestRetryPendingGivesUp(t *testing.T) {
+	feedURL := "http://example.com/feed"
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: feedURL}}},
+		},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {
+				Feeds: map[string]state.FeedState{
+					feedURL: {Pending: []state.PendingItem{
+						{Item: rss.FeedItem{ID: "a", Title: "Almost"}, Attempts: maxDeliveryAttempts - 1},
+						{Item: rss.FeedItem{ID: "b", Title: "Recent"}, Attempts: 1},
+					}},
+				},
+			},
+		},
+	}
+
+	retryPending(context.Background(), cfg, &currentState, &mockSlackClient{err: errors.New("channel_not_found")})
+
+	pending := currentState.Channels["test-channel"].Feeds[feedURL].Pending
+	if len(pending) != 1 || pending[0].Item.ID != "b" || pending[0].Attempts != 2 {
+		t.Errorf("Expected only the recent item to stay pending, got %+v", pending)
+	}
+}
+
+func TestProcessFeedsTemplates(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "releases",
+				Feeds: []config.Feed{
+					{URL: "http://example.com/feed"},
+					{URL: "http://example.com/other", Template: "{{ .Title | truncate 8 }}"},
+				},
+				Template: "{{ .FeedTitle }}: <{{ .Link }}|{{ .Title }}>",
+			},
+		},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"releases": {
+				Feeds: map[string]state.FeedState{
+					"http://example.com/feed":  {LastUpdated: lastUpdated},
+					"http://example.com/other": {LastUpdated: lastUpdated},
+				},
+			},
+		},
+	}
+	mockSlack := &mockSlackClient{}
+	mockRSS := &mockRSSClient{
+		items: []rss.FeedItem{
+			{Title: "Version 2.0", Link: "http://example.com/v2", Published: lastUpdated.Add(time.Hour), FeedTitle: "Releases"},
+		},
+	}
+
+	processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+	expected := []string{"Releases: <http://example.com/v2|Version 2.0>", "Version…"}
+	if len(mockSlack.messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(mockSlack.messages))
+	}
+	for i, text := range expected {
+		if mockSlack.messages[i].text != text {
+			t.Errorf("message %d = %q, want %q", i, mockSlack.messages[i].text, text)
+		}
+	}
+}
+
+func TestProcessFeedsFeedSettings(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	disabled := false
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "test-channel",
+				Feeds: []config.Feed{
+					{URL: "http://example.com/off", Enabled: &disabled},
+					{
+						URL:     "http://example.com/private",
+						Name:    "Private Blog",
+						Headers: map[string]string{"Authorization": "Bearer secret"},
+					},
+				},
+			},
+		},
+	}
+	offState := state.FeedState{LastUpdated: lastUpdated, SeenIDs: []string{"kept"}}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {
+				Feeds: map[string]state.FeedState{
+					"http://example.com/off":     offState,
+					"http://example.com/private": {LastUpdated: lastUpdated},
+				},
+			},
+		},
+	}
+	mockSlack := &mockSlackClient{}
+	mockRSS := &mockRSSClient{
+		items: []rss.FeedItem{
+			{Title: "Secret Post", Link: "http://example.com/secret", Published: lastUpdated.Add(time.Hour), FeedTitle: "Feed Title"},
+		},
+	}
+
+	updateSubscriptions(context.Background(), cfg, &currentState, mockRSS)
+	feedsProcessed, _ := processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+	if feedsProcessed != 1 || len(mockRSS.fetched) != 1 || mockRSS.fetched[0] != "http://example.com/private" {
+		t.Errorf("expected only the enabled feed to be fetched, got %v", mockRSS.fetched)
+	}
+	if mockRSS.gotHeaders["Authorization"] != "Bearer secret" {
+		t.Errorf("expected feed headers to be sent, got %v", mockRSS.gotHeaders)
+	}
+	if len(mockSlack.messages) != 1 || !contains(mockSlack.messages[0].text, "New post from Private Blog") {
+		t.Errorf("expected post using the feed name, got %+v", mockSlack.messages)
+	}
+	if got := currentState.Channels["test-channel"].Feeds["http://example.com/off"]; len(got.SeenIDs) != 1 {
+		t.Errorf("expected disabled feed state to be kept, got %+v", got)
+	}
+}
+
+func TestProcessFeedsFilters(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "test-channel",
+				Filters:      config.Filters{Exclude: []string{"hiring"}},
+				Feeds: []config.Feed{
+					{URL: "http://example.com/feed", Filters: config.Filters{Include: []string{"/^Release/"}}},
+				},
+			},
+		},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {
+				Feeds: map[string]state.FeedState{
+					"http://example.com/feed": {LastUpdated: lastUpdated},
+				},
+			},
+		},
+	}
+	mockSlack := &mockSlackClient{}
+	mockRSS := &mockRSSClient{
+		items: []rss.FeedItem{
+			{Title: "Release v2", Link: "http://example.com/v2", Published: lastUpdated.Add(time.Hour)},
+			{Title: "Release team is hiring", Link: "http://example.com/jobs", Published: lastUpdated.Add(2 * time.Hour)},
+			{Title: "Company picnic", Link: "http://example.com/picnic", Published: lastUpdated.Add(3 * time.Hour)},
+		},
+	}
+
+	_, postsFound := processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+	if postsFound != 1 || len(mockSlack.messages) != 1 || !contains(mockSlack.messages[0].text, "Release v2") {
+		t.Errorf("expected only the release to be posted, got %+v", mockSlack.messages)
+	}
+	// Filtered items are still recorded so they aren't reconsidered next run
+	if seen := currentState.Channels["test-channel"].Feeds["http://example.com/feed"].Seen(); !seen["http://example.com/picnic"] {
+		t.Error("expected filtered items to be marked as seen")
+	}
+}
+
+func TestProcessFeedsConditionalFetch(t *testing.T) {
+	lastUpdated := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "test-channel",
+				Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
+			},
+		},
+	}
+
+	t.Run("stores validators from fetch", func(t *testing.T) {
+		currentState := state.State{
+			Channels: map[string]state.ChannelState{
+				"test-channel": {
+					Feeds: map[string]state.FeedState{
+						"http://example.com/feed": {LastUpdated: lastUpdated},
+					},
+				},
+			},
+		}
+		mockRSS := &mockRSSClient{
+			items: []rss.FeedItem{
+				{Title: "New Post", Link: "http://example.com/new", Published: lastUpdated.Add(time.Hour)},
+			},
+			cache: rss.CacheInfo{ETag: `"abc"`, LastModified: "Fri, 25 Jul 2025 13:00:00 GMT"},
+		}
+
+		processFeeds(context.Background(), cfg, &currentState, &mockSlackClient{}, mockRSS)
+
+		feedState := currentState.Channels["test-channel"].Feeds["http://example.com/feed"]
+		if feedState.ETag != `"abc"` {
+			t.Errorf("expected ETag to be stored, got %q", feedState.ETag)
+		}
+		if feedState.LastModified != "Fri, 25 Jul 2025 13:00:00 GMT" {
+			t.Errorf("expected LastModified to be stored, got %q", feedState.LastModified)
+		}
+	})
+
+	t.Run("not modified posts nothing", func(t *testing.T) {
+		currentState := state.State{
+			Channels: map[string]state.ChannelState{
+				"test-channel": {
+					Feeds: map[string]state.FeedState{
+						"http://example.com/feed": {LastUpdated: lastUpdated, ETag: `"abc"`},
+					},
+				},
+			},
+		}
+		mockSlack := &mockSlackClient{}
+		mockRSS := &mockRSSClient{notModified: true}
+
+		processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+		if mockRSS.gotCache.ETag != `"abc"` {
+			t.Errorf("expected stored ETag to be sent, got %q", mockRSS.gotCache.ETag)
+		}
+		if len(mockSlack.messages) != 0 {
+			t.Errorf("expected no messages, got %d", len(mockSlack.messages))
+		}
+		feedState := currentState.Channels["test-channel"].Feeds["http://example.com/feed"]
+		if !feedState.LastUpdated.Equal(lastUpdated) || feedState.ETag != `"abc"` {
+			t.Errorf("expected feed state to be unchanged, got %+v", feedState)
+		}
+	})
+}
+
+// slowRSSClient serves one item per feed after a delay and records the peak
+// number of concurrent fetches overall and per host.
+type slowRSSClient struct {
+	delays map[string]time.Duration
+
+	inFlight     atomic.Int32
+	peak         atomic.Int32
+	mu           sync.Mutex
+	hostInFlight map[string]int
+	hostPeak     map[string]int
+}
+
+// slowSlackClient takes delay to post each message.
+type slowSlackClient struct {
+	mockSlackClient
+	delay time.Duration
+}
+
+func (m *slowSlackClient) PostMessage(channel string, msg slack.Message) (string, string, error) {
+	time.Sleep(m.delay)
+	return m.mockSlackClient.PostMessage(channel, msg)
+}
+
+func (m *slowRSSClient) FetchFeed(ctx context.Context, req rss.Request) (rss.FetchResult, error) {
+	url := req.URL
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		peak := m.peak.Load()
+		if n <= peak || m.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	host := hostOf(url)
+	m.mu.Lock()
+	m.hostInFlight[host]++
+	m.hostPeak[host] = max(m.hostPeak[host], m.hostInFlight[host])
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.hostInFlight[host]--
+		m.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(m.delays[url]):
+	case <-ctx.Done():
+		return rss.FetchResult{}, ctx.Err()
+	}
+	return rss.FetchResult{Items: []rss.FeedItem{
+		{ID: url, Title: "Post from " + url, Link: url, Published: time.Now()},
+	}}, nil
+}
+
+func TestProcessFeedsConcurrent(t *testing.T) {
+	var feedsA, feedsB []config.Feed
+	delays := make(map[string]time.Duration)
+	for i := 0; i < 4; i++ {
+		a := fmt.Sprintf("http://a.example.com/feed%d", i)
+		b := fmt.Sprintf("http://b.example.com/feed%d", i)
+		feedsA = append(feedsA, config.Feed{URL: a})
+		feedsB = append(feedsB, config.Feed{URL: b})
+		// Earlier feeds are slower so completion order differs from config order
+		delays[a] = time.Duration(4-i) * 10 * time.Millisecond
+		delays[b] = time.Duration(4-i) * 10 * time.Millisecond
+	}
+
+	cfg := config.Config{
+		Concurrency:        3,
+		PerHostConcurrency: 2,
+		Channels: []config.Channel{
+			{SlackChannel: "channel-a", Feeds: feedsA},
+			{SlackChannel: "channel-b", Feeds: feedsB},
+		},
+	}
+	currentState := state.State{Channels: make(map[string]state.ChannelState)}
+	for _, ch := range cfg.Channels {
+		feeds := make(map[string]state.FeedState)
+		for _, f := range ch.Feeds {
+			feeds[f.URL] = state.FeedState{LastUpdated: time.Now().Add(-time.Hour)}
+		}
+		currentState.Channels[ch.SlackChannel] = state.ChannelState{Feeds: feeds}
+	}
+
+	mockSlack := &mockSlackClient{}
+	mockRSS := &slowRSSClient{
+		delays:       delays,
+		hostInFlight: make(map[string]int),
+		hostPeak:     make(map[string]int),
+	}
+
+	feedsProcessed, postsFound := processFeeds(context.Background(), cfg, &currentState, mockSlack, mockRSS)
+
+	if feedsProcessed != 8 || postsFound != 8 {
+		t.Fatalf("expected 8 feeds and 8 posts, got %d and %d", feedsProcessed, postsFound)
+	}
+	if peak := mockRSS.peak.Load(); peak > 3 {
+		t.Errorf("expected at most 3 concurrent fetches, got %d", peak)
+	}
+	for host, peak := range mockRSS.hostPeak {
+		if peak > 2 {
+			t.Errorf("expected at most 2 concurrent fetches to %s, got %d", host, peak)
+		}
+	}
+
+	expected := append(append([]config.Feed{}, feedsA...), feedsB...)
+	for i, feed := range expected {
+		if !contains(mockSlack.messages[i].text, feed.URL) {
+			t.Errorf("message %d = %q, expected post from %s", i, mockSlack.messages[i].text, feed.URL)
+		}
+	}
+}
+
+func TestProcessFeedsTimeoutAndCancel(t *testing.T) {
+	const fast = "http://fast.example.com/feed"
+	const slow = "http://slow.example.com/feed"
+	cfg := config.Config{
+		FeedTimeout: 50 * time.Millisecond,
+		Channels: []config.Channel{
+			{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: fast}, {URL: slow}}},
+		},
+	}
+	lastUpdated := time.Now().Add(-time.Hour)
+	newState := func() state.State {
+		return state.State{
+			Channels: map[string]state.ChannelState{
+				"test-channel": {
+					Feeds: map[string]state.FeedState{
+						fast: {LastUpdated: lastUpdated},
+						slow: {LastUpdated: lastUpdated},
+					},
+				},
+			},
+		}
+	}
+	newClient := func() *slowRSSClient {
+		return &slowRSSClient{
+			delays:       map[string]time.Duration{slow: time.Minute},
+			hostInFlight: make(map[string]int),
+			hostPeak:     make(map[string]int),
+		}
+	}
+
+	t.Run("per-feed timeout", func(t *testing.T) {
+		currentState := newState()
+		mockSlack := &mockSlackClient{}
+
+		start := time.Now()
+		_, postsFound := processFeeds(context.Background(), cfg, &currentState, mockSlack, newClient())
+
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("expected slow feed to time out, took %v", elapsed)
+		}
+		if postsFound != 1 || len(mockSlack.messages) != 1 {
+			t.Errorf("expected only the fast feed to post, got %d posts", postsFound)
+		}
+		if !currentState.Channels["test-channel"].Feeds[slow].LastUpdated.Equal(lastUpdated) {
+			t.Error("expected timed out feed state to be unchanged")
+		}
+	})
+
+	t.Run("run timeout", func(t *testing.T) {
+		currentState := newState()
+		runCfg := cfg
+		runCfg.Timeout = 50 * time.Millisecond
+		runCfg.FeedTimeout = time.Minute
+
+		processFeeds(context.Background(), runCfg, &currentState, &mockSlackClient{}, newClient())
+
+		fs := currentState.Channels["test-channel"].Feeds[slow]
+		if fs.Failures != 0 || fs.LastError != "" {
+			t.Errorf("expected the run timeout not to count as a feed failure, got %+v", fs)
+		}
+	})
+
+	t.Run("run timeout passes while posting", func(t *testing.T) {
+		currentState := newState()
+		runCfg := cfg
+		runCfg.Timeout = 200 * time.Millisecond
+		// Posting the fast feed's item outlasts the run timeout, after the slow
+		// feed already failed on its own timeout
+		poster := &slowSlackClient{delay: 300 * time.Millisecond}
+
+		processFeeds(context.Background(), runCfg, &currentState, poster, newClient())
+
+		fs := currentState.Channels["test-channel"].Feeds[slow]
+		if fs.Failures != 1 {
+			t.Errorf("expected the per-feed timeout to count as a failure, got %+v", fs)
+		}
+	})
+
+	t.Run("cancelled run", func(t *testing.T) {
+		currentState := newState()
+		mockSlack := &mockSlackClient{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		feedsProcessed, _ := processFeeds(ctx, cfg, &currentState, mockSlack, newClient())
+
+		if feedsProcessed != 0 || len(mockSlack.messages) != 0 {
+			t.Errorf("expected nothing processed after cancel, got %d feeds and %d messages", feedsProcessed, len(mockSlack.messages))
+		}
+		for feedURL, fs := range currentState.Channels["test-channel"].Feeds {
+			if !fs.LastUpdated.Equal(lastUpdated) || len(fs.SeenIDs) != 0 {
+				t.Errorf("expected state for %s to be unchanged, got %+v", feedURL, fs)
+			}
+		}
+	})
+}
+
+func TestFetchAllCancelledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jobs := []fetchJob{
+		{request: rss.Request{URL: "http://example.com/a"}},
+		{request: rss.Request{URL: "http://example.com/b"}},
+	}
+
+	outcomes := fetchAll(ctx, jobs, &mockRSSClient{}, 1, 1)
+
+	for i, outcome := range outcomes {
+		if outcome.err != nil && !errors.Is(outcome.err, context.Canceled) {
+			t.Errorf("job %d: expected context.Canceled, got %v", i, outcome.err)
+		}
+	}
+}
+
 func contains(message, title string) bool {
 	return strings.Contains(message, title)
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/opml"
+)
+
+const opmlUsage = `usage:
+  opml import [-config file] [-channel name] <file.opml>
+  opml export [-config file] [-o file.opml]`
+
+// runOPML handles the opml import and export subcommands.
+func runOPML(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(opmlUsage)
+	}
+	switch args[0] {
+	case "import":
+		return runOPMLImport(args[1:])
+	case "export":
+		return runOPMLExport(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown opml command %q\n%s", args[0], opmlUsage)
+	}
+}
+
+func runOPMLImport(args []string) error {
+	flags := flag.NewFlagSet("opml import", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file to merge feeds into")
+	channel := flags.String("channel", "", "channel for feeds that are not in a folder")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(opmlUsage)
+	}
+
+	cfg, err := config.LoadConfig(*configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Config %s does not exist, creating it", *configFile)
+		cfg, err = config.Config{}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	doc, err := opml.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	added, err := opml.Import(&cfg, doc, *channel)
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		log.Printf("No new feeds in %s", flags.Arg(0))
+		return nil
+	}
+	if err := config.SaveConfig(*configFile, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	log.Printf("Imported %d feeds into %s", added, *configFile)
+	return nil
+}
+
+func runOPMLExport(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("opml export", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file to export")
+	output := flags.String("o", "", "write OPML to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return opml.Export(cfg).Write(w)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slack-rss-feed-manager/config"
+)
+
+func TestRunOPML(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	opmlFile := filepath.Join(dir, "feeds.opml")
+
+	err := os.WriteFile(configFile, []byte(`channels:
+  - slack_channel: tech-blog-alerts
+    feeds:
+      - https://antirez.com/rss
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(opmlFile, []byte(`<opml version="2.0"><body>
+  <outline text="tech-blog-alerts">
+    <outline text="antirez" type="rss" xmlUrl="https://antirez.com/rss"/>
+    <outline text="Go" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+  </outline>
+</body></opml>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runOPML([]string{"import", "-config", configFile, opmlFile}, nil); err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feeds := cfg.Channels[0].Feeds; len(feeds) != 2 || feeds[1].URL != "https://go.dev/blog/feed.atom" {
+		t.Errorf("Expected Go blog to be added once, got %+v", feeds)
+	}
+
+	var out bytes.Buffer
+	if err := runOPML([]string{"export", "-config", configFile}, &out); err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	if strings.Count(out.String(), "xmlUrl=") != 2 {
+		t.Errorf("Expected 2 feeds in export, got:\n%s", out.String())
+	}
+
+	if err := runOPML([]string{"bogus"}, nil); err == nil {
+		t.Error("Expected error for unknown command")
+	}
+}
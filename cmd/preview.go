@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+const previewUsage = `usage:
+  preview [-config file] [-channel name] [-n count] [-format text|json] <feed-url>`
+
+// messagePrinter stands in for Slack in dry runs and previews, writing each
+// message to w instead of posting it.
+type messagePrinter struct {
+	w      io.Writer
+	asJSON bool
+	// printed counts the messages printed, which serves as their timestamps.
+	printed int
+}
+
+func newMessagePrinter(w io.Writer, format string) (*messagePrinter, error) {
+	switch format {
+	case "text":
+		return &messagePrinter{w: w}, nil
+	case "json":
+		return &messagePrinter{w: w, asJSON: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected text or json", format)
+	}
+}
+
+// PostMessage prints the message. Text output shows the channel and the
+// message text; JSON output is one object per line, including the blocks
+// exactly as they would be sent. The channel's name stands in for its ID.
+func (p *messagePrinter) PostMessage(channel string, msg slack.Message) (string, string, error) {
+	p.printed++
+	ts := strconv.Itoa(p.printed)
+	if p.asJSON {
+		data, err := json.Marshal(struct {
+			Channel  string      `json:"channel"`
+			TS       string      `json:"ts"`
+			ThreadTS string      `json:"thread_ts,omitempty"`
+			Text     string      `json:"text"`
+			Blocks   interface{} `json:"blocks,omitempty"`
+		}{channel, ts, msg.ThreadTS, msg.Text, msg.Blocks})
+		if err != nil {
+			return "", "", err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return channel, ts, err
+	}
+	// Replies are printed right after the message starting their thread
+	if msg.ThreadTS != "" {
+		channel += " (in thread)"
+	}
+	_, err := fmt.Fprintf(p.w, "--- %s\n%s\n\n", channel, msg.Text)
+	return channel, ts, err
+}
+
+// UpdateMessage prints the new version of a message like PostMessage does,
+// marked as an update.
+func (p *messagePrinter) UpdateMessage(channelID, ts string, msg slack.Message) error {
+	if p.asJSON {
+		data, err := json.Marshal(struct {
+			Channel string      `json:"channel"`
+			TS      string      `json:"ts"`
+			Update  bool        `json:"update"`
+			Text    string      `json:"text"`
+			Blocks  interface{} `json:"blocks,omitempty"`
+		}{channelID, ts, true, msg.Text, msg.Blocks})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "--- %s (update)\n%s\n\n", channelID, msg.Text)
+	return err
+}
+
+// Do prints the body of a webhook request instead of sending it, and answers
+// as if the webhook had accepted it. Only the URL's host is shown, since
+// webhook URLs usually carry a secret.
+func (p *messagePrinter) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if p.asJSON {
+		data, err := json.Marshal(struct {
+			Webhook string          `json:"webhook"`
+			Payload json.RawMessage `json:"payload"`
+		}{req.URL.Host, body})
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+	} else {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			return nil, err
+		}
+		_, err = fmt.Fprintf(p.w, "--- %s\n%s\n\n", req.URL.Host, indented.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// SendMail prints an email instead of sending it.
+func (p *messagePrinter) SendMail(from string, to []string, msg []byte) error {
+	if p.asJSON {
+		data, err := json.Marshal(struct {
+			To      []string `json:"to"`
+			Message string   `json:"message"`
+		}{to, string(msg)})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "--- email to %s\n%s\n\n", strings.Join(to, ", "), msg)
+	return err
+}
+
+// runPreview handles the preview subcommand: it fetches a feed and prints its
+// latest items as they would be posted, using the template of the channel the
+// feed is configured in. Filters are not applied, so every item is shown.
+func runPreview(args []string, stdout io.Writer, rssClient RSSClient) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	channelName := flags.String("channel", "", "channel whose formatting is used (default: the channel the feed is in)")
+	count := flags.Int("n", 5, "number of items to show")
+	format := flags.String("format", "text", "output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *count < 1 {
+		return errors.New(previewUsage)
+	}
+	feedURL := flags.Arg(0)
+	printer, err := newMessagePrinter(stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return err
+	}
+	ch, feed, err := findFeed(&cfg, *channelName, feedURL)
+	if err != nil {
+		return err
+	}
+
+	outcome := fetchOne(context.Background(), rssClient, fetchJob{
+		request: rss.Request{URL: feed.URL, Headers: feed.Headers, PublicOnly: feed.FromSlack},
+		timeout: cfg.FetchTimeout(feed),
+	})
+	if outcome.err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", feedURL, outcome.err)
+	}
+
+	// Newest first to pick the latest items, then printed in posting order
+	items := outcome.result.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	items = items[:min(*count, len(items))]
+	notifier := notifierFor(cfg, ch, feed, printer)
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if feed.Name != "" {
+			item.FeedTitle = feed.Name
+		}
+		if err := notifier.Notify(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findFeed looks up feedURL in the named channel, or in any channel when name
+// is empty. A feed that isn't configured is previewed with that channel's
+// formatting, or the default formatting when no channel is named.
+func findFeed(cfg *config.Config, name, feedURL string) (config.Channel, config.Feed, error) {
+	for _, ch := range cfg.Channels {
+		if name != "" && ch.SlackChannel != name {
+			continue
+		}
+		for _, feed := range ch.Feeds {
+			if feed.URL == feedURL {
+				return ch, feed, nil
+			}
+		}
+	}
+	feed := config.Feed{URL: feedURL}
+	if name == "" {
+		return config.Channel{SlackChannel: "preview"}, feed, nil
+	}
+	ch := cfg.Channel(name)
+	if ch == nil {
+		return config.Channel{}, config.Feed{}, fmt.Errorf("channel %s is not in the config", name)
+	}
+	return *ch, feed, nil
+}
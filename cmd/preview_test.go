@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+	"slack-rss-feed-manager/state"
+)
+
+func TestMessagePrinter(t *testing.T) {
+	item := rss.FeedItem{ID: "a", Title: "Hello", Link: "http://example.com/a", FeedTitle: "Blog"}
+	cfg := config.Config{Channels: []config.Channel{
+		{SlackChannel: "test-channel", Feeds: []config.Feed{{URL: "http://example.com/feed"}}},
+	}}
+	newState := func() state.State {
+		return state.State{Channels: map[string]state.ChannelState{
+			"test-channel": {Feeds: map[string]state.FeedState{
+				"http://example.com/feed": {LastUpdated: time.Now().Add(-2 * time.Hour)},
+			}},
+		}}
+	}
+	item.Published = time.Now().Add(-time.Hour)
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		printer, err := newMessagePrinter(&out, "text")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newState()
+		processFeeds(context.Background(), cfg, &s, printer, &mockRSSClient{items: []rss.FeedItem{item}})
+
+		if !strings.Contains(out.String(), "--- #test-channel\n") || !strings.Contains(out.String(), "Hello") {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		printer, err := newMessagePrinter(&out, "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newState()
+		processFeeds(context.Background(), cfg, &s, printer, &mockRSSClient{items: []rss.FeedItem{item}})
+
+		var msg struct {
+			Channel string
+			Text    string
+			Blocks  []map[string]any
+		}
+		if err := json.Unmarshal(out.Bytes(), &msg); err != nil {
+			t.Fatalf("Output is not JSON: %v\n%s", err, out.String())
+		}
+		if msg.Channel != "#test-channel" || !strings.Contains(msg.Text, "Hello") || len(msg.Blocks) == 0 {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		var out bytes.Buffer
+		printer, err := newMessagePrinter(&out, "text")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := printer.UpdateMessage("C123", "1", slack.Message{Text: "Edited"}); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "--- C123 (update)\nEdited\n\n" {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		var out bytes.Buffer
+		printer, err := newMessagePrinter(&out, "text")
+		if err != nil {
+			t.Fatal(err)
+		}
+		webhookCfg := config.Config{Channels: []config.Channel{{
+			SlackChannel: "test-channel",
+			Target:       config.Target{Type: "discord", URL: "https://discord.com/api/webhooks/1/secret"},
+			Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
+		}}}
+		s := newState()
+		processFeeds(context.Background(), webhookCfg, &s, printer, &mockRSSClient{items: []rss.FeedItem{item}})
+
+		if !strings.HasPrefix(out.String(), "--- discord.com\n") || !strings.Contains(out.String(), `"title": "Hello"`) {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+		if strings.Contains(out.String(), "secret") {
+			t.Errorf("Webhook URL leaked into output:\n%s", out.String())
+		}
+		if s.Channels["test-channel"].Feeds["http://example.com/feed"].Delivered == nil {
+			t.Error("Expected the printed webhook to count as delivered")
+		}
+	})
+
+	t.Run("email", func(t *testing.T) {
+		var out bytes.Buffer
+		printer, err := newMessagePrinter(&out, "text")
+		if err != nil {
+			t.Fatal(err)
+		}
+		emailCfg := config.Config{
+			SMTP: config.SMTP{Host: "smtp.example.com", From: "rss@example.com"},
+			Channels: []config.Channel{{
+				SlackChannel: "test-channel",
+				Target:       config.Target{Type: "email", To: []string{"team@example.com"}},
+				Feeds:        []config.Feed{{URL: "http://example.com/feed"}},
+			}},
+		}
+		s := newState()
+		processFeeds(context.Background(), emailCfg, &s, printer, &mockRSSClient{items: []rss.FeedItem{item}})
+
+		if !strings.HasPrefix(out.String(), "--- email to team@example.com\n") || !strings.Contains(out.String(), "Subject: Blog: Hello") {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+	})
+
+	if _, err := newMessagePrinter(nil, "xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestRunPreview(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`channels:
+  - slack_channel: test-channel
+    template: "{{.Title}} from {{.FeedTitle}}"
+    feeds:
+      - url: http://example.com/feed
+        name: Example
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{
+		{Title: "Oldest", Link: "http://example.com/1", Published: now.Add(-3 * time.Hour)},
+		{Title: "Newest", Link: "http://example.com/3", Published: now.Add(-time.Hour)},
+		{Title: "Middle", Link: "http://example.com/2", Published: now.Add(-2 * time.Hour)},
+	}}
+
+	var out bytes.Buffer
+	if err := runPreview([]string{"-config", configFile, "-n", "2", "http://example.com/feed"}, &out, mockRSS); err != nil {
+		t.Fatalf("runPreview() error = %v", err)
+	}
+	want := "--- #test-channel\nMiddle from Example\n\n--- #test-channel\nNewest from Example\n\n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+
+	// Feeds that aren't configured use the default formatting
+	out.Reset()
+	if err := runPreview([]string{"-config", configFile, "-n", "1", "http://example.com/other"}, &out, mockRSS); err != nil {
+		t.Fatalf("runPreview() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "--- #preview\n") || !strings.Contains(out.String(), "Newest") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	if err := runPreview([]string{"-config", configFile, "-channel", "missing", "http://example.com/other"}, &out, mockRSS); err == nil {
+		t.Error("Expected error for unknown channel")
+	}
+	if err := runPreview([]string{"-config", configFile}, &out, mockRSS); err == nil {
+		t.Error("Expected usage error without a feed URL")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	st "slack-rss-feed-manager/state"
+)
+
+// publishingSample is how many of the most recent item dates are used to
+// estimate how often a feed publishes.
+const publishingSample = 10
+
+// scheduleNext records a successful check at now and works out when the feed
+// is next due. Feeds without a poll interval are due on every run.
+func scheduleNext(feedState *st.FeedState, feed config.Feed, items []rss.FeedItem, now time.Time) {
+	previous := feedState.NextDue.Sub(feedState.LastChecked)
+	feedState.LastChecked = now
+
+	interval := feed.PollInterval
+	if !feed.AdaptivePoll.IsZero() {
+		interval = adaptiveInterval(feed.AdaptivePoll, items, previous)
+	}
+	if interval <= 0 {
+		feedState.NextDue = time.Time{}
+		return
+	}
+	feedState.NextDue = now.Add(interval)
+}
+
+// adaptiveInterval polls at half the median gap between recent items, within
+// the bounds. Without enough dated items to tell (such as after a 304) the
+// previous interval is kept, starting from the minimum.
+func adaptiveInterval(bounds config.PollBounds, items []rss.FeedItem, previous time.Duration) time.Duration {
+	var dates []time.Time
+	for _, item := range items {
+		if !item.Published.IsZero() {
+			dates = append(dates, item.Published)
+		}
+	}
+	if len(dates) < 2 {
+		if previous <= 0 {
+			return bounds.Min
+		}
+		return min(max(previous, bounds.Min), bounds.Max)
+	}
+
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	if len(dates) > publishingSample {
+		dates = dates[:publishingSample]
+	}
+	gaps := make([]time.Duration, 0, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		gaps = append(gaps, dates[i-1].Sub(dates[i]))
+	}
+	slices.Sort(gaps)
+	interval := gaps[len(gaps)/2] / 2
+	return min(max(interval, bounds.Min), bounds.Max)
+}
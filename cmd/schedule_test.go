@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/state"
+)
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+
+	t.Run("no interval is always due", func(t *testing.T) {
+		var fs state.FeedState
+		scheduleNext(&fs, config.Feed{}, nil, now)
+		if !fs.LastChecked.Equal(now) || !fs.NextDue.IsZero() {
+			t.Errorf("unexpected schedule %+v", fs)
+		}
+		if !fs.Due(now) {
+			t.Error("expected feed to be due")
+		}
+	})
+
+	t.Run("fixed interval", func(t *testing.T) {
+		var fs state.FeedState
+		scheduleNext(&fs, config.Feed{PollInterval: 24 * time.Hour}, nil, now)
+		if !fs.NextDue.Equal(now.Add(24 * time.Hour)) {
+			t.Errorf("expected next due in 24h, got %v", fs.NextDue)
+		}
+		if fs.Due(now.Add(23*time.Hour)) || !fs.Due(now.Add(24*time.Hour)) {
+			t.Error("expected feed to become due after 24h")
+		}
+	})
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	bounds := config.PollBounds{Min: time.Hour, Max: 7 * 24 * time.Hour}
+	base := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	every := func(gap time.Duration, n int) []rss.FeedItem {
+		var items []rss.FeedItem
+		for i := 0; i < n; i++ {
+			items = append(items, rss.FeedItem{Published: base.Add(-time.Duration(i) * gap)})
+		}
+		return items
+	}
+
+	tests := []struct {
+		name     string
+		items    []rss.FeedItem
+		previous time.Duration
+		expected time.Duration
+	}{
+		{"daily posts", every(24*time.Hour, 5), 0, 12 * time.Hour},
+		{"frequent posts clamp to min", every(10*time.Minute, 5), 0, time.Hour},
+		{"monthly posts clamp to max", every(30*24*time.Hour, 5), 0, 7 * 24 * time.Hour},
+		{"undated items start at min", []rss.FeedItem{{}, {}}, 0, time.Hour},
+		{"no items keep previous", nil, 6 * time.Hour, 6 * time.Hour},
+		{"outlier gap ignored", append(every(24*time.Hour, 4), rss.FeedItem{Published: base.Add(-365 * 24 * time.Hour)}), 0, 12 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adaptiveInterval(bounds, tt.items, tt.previous); got != tt.expected {
+				t.Errorf("adaptiveInterval() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessFeedsSkipsFeedsNotDue(t *testing.T) {
+	now := time.Now()
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "test-channel",
+				Feeds: []config.Feed{
+					{URL: "http://example.com/monthly", PollInterval: 30 * 24 * time.Hour},
+					{URL: "http://example.com/news", PollInterval: time.Hour},
+				},
+			},
+		},
+	}
+	currentState := state.State{
+		Channels: map[string]state.ChannelState{
+			"test-channel": {
+				Feeds: map[string]state.FeedState{
+					"http://example.com/monthly": {LastUpdated: now.Add(-2 * time.Hour), LastChecked: now.Add(-time.Hour), NextDue: now.Add(29 * 24 * time.Hour)},
+					"http://example.com/news":    {LastUpdated: now.Add(-2 * time.Hour), LastChecked: now.Add(-time.Hour), NextDue: now.Add(-time.Minute)},
+				},
+			},
+		},
+	}
+	mockRSS := &mockRSSClient{}
+
+	feedsProcessed, _ := processFeeds(context.Background(), cfg, &currentState, &mockSlackClient{}, mockRSS)
+
+	if feedsProcessed != 1 || len(mockRSS.fetched) != 1 || mockRSS.fetched[0] != "http://example.com/news" {
+		t.Errorf("expected only the due feed to be fetched, got %v", mockRSS.fetched)
+	}
+	news := currentState.Channels["test-channel"].Feeds["http://example.com/news"]
+	if news.LastChecked.Before(now) || !news.NextDue.Equal(news.LastChecked.Add(time.Hour)) {
+		t.Errorf("expected news feed to be rescheduled an hour after this check, got %+v", news)
+	}
+}
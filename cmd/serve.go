@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+// commandFetchTimeout bounds checking a feed while answering a slash command,
+// since Slack gives up on a response after three seconds.
+const commandFetchTimeout = 2 * time.Second
+
+const commandHelp = "Usage:\n" +
+	"• `/rss subscribe <feed-url>` posts new items from the feed to this channel\n" +
+	"• `/rss unsubscribe <feed-url>` stops posting them\n" +
+	"• `/rss list` shows the feeds posted to this channel"
+
+// runServe handles the serve subcommand: an HTTP server for the /rss slash
+// command, until it receives SIGINT or SIGTERM. Posting is left to normal or
+// daemon runs, which pick up the new subscriptions.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configFile := flags.String("config", "config.yaml", "config file")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		return errors.New("SLACK_SIGNING_SECRET not set")
+	}
+	cfg, err := config.LoadMerged(*configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Subscriptions == "" {
+		return errors.New("set subscriptions in the config to the file that holds subscriptions made from Slack")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/slack/command", &commandHandler{
+		configFile:    *configFile,
+		signingSecret: secret,
+		rssClient:     &defaultRSSClient{},
+	})
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening for slash commands on %s/slack/command", *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// commandHandler serves the /rss slash command. Subscriptions made from Slack
+// go to the subscriptions file named in the config; feeds in the config file
+// itself can only be changed there.
+type commandHandler struct {
+	configFile    string
+	signingSecret string
+	rssClient     RSSClient
+	// lookupIP resolves the hosts of feeds to subscribe to; nil means the
+	// default resolver.
+	lookupIP func(ctx context.Context, network, host string) ([]net.IP, error)
+
+	// mu serializes changes to the subscriptions file
+	mu sync.Mutex
+}
+
+func (h *commandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cmd, err := slack.ParseCommand(r, h.signingSecret)
+	if err != nil {
+		log.Printf("Rejected slash command request: %v", err)
+		http.Error(w, "invalid request", http.StatusUnauthorized)
+		return
+	}
+	log.Printf("Slash command from %s in #%s: %s %s", cmd.UserName, cmd.ChannelName, cmd.Name, cmd.Text)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"response_type": "ephemeral",
+		"text":          h.handle(cmd),
+	})
+}
+
+// handle runs a command and returns the reply shown to the user.
+func (h *commandHandler) handle(cmd slack.Command) string {
+	// Slack names every direct message and group DM alike, so they can't be
+	// told apart as channels
+	if cmd.ChannelName == "directmessage" || cmd.ChannelName == "privategroup" {
+		return "Feeds can only be managed from a channel, not from direct messages."
+	}
+	action, arg, _ := strings.Cut(strings.TrimSpace(cmd.Text), " ")
+	feedURL := commandURL(arg)
+	switch {
+	case action == "subscribe" && feedURL != "":
+		return h.subscribe(cmd, feedURL)
+	case action == "unsubscribe" && feedURL != "":
+		return h.unsubscribe(cmd, feedURL)
+	case action == "list":
+		return h.list(cmd)
+	default:
+		return commandHelp
+	}
+}
+
+func (h *commandHandler) subscribe(cmd slack.Command, feedURL string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cfg, err := config.LoadMerged(h.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return "Sorry, the feed configuration can't be loaded right now."
+	}
+	if cfg.HasFeed(cmd.ChannelName, feedURL) {
+		return fmt.Sprintf("This channel is already subscribed to %s.", feedURL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandFetchTimeout)
+	defer cancel()
+	if err := h.checkFeedURL(ctx, feedURL); err != nil {
+		log.Printf("Refused to fetch %s for %s: %v", feedURL, cmd.UserName, err)
+		return "Only feeds on public http or https addresses can be subscribed to."
+	}
+	feed := config.Feed{URL: feedURL}
+	outcome := fetchOne(ctx, h.rssClient, fetchJob{
+		request: rss.Request{URL: feedURL, PublicOnly: true},
+		timeout: cfg.FetchTimeout(feed),
+	})
+	if outcome.err != nil {
+		log.Printf("Failed to fetch %s for %s: %v", feedURL, cmd.UserName, outcome.err)
+		return fmt.Sprintf("Couldn't read a feed from %s. Check that the URL points at an RSS or Atom feed.", feedURL)
+	}
+
+	subs, err := config.LoadSubscriptions(cfg.Subscriptions)
+	if err != nil {
+		log.Printf("Failed to load subscriptions: %v", err)
+		return "Sorry, the subscriptions can't be loaded right now."
+	}
+	subs = append(subs, config.Subscription{
+		Channel: cmd.ChannelName,
+		URL:     feedURL,
+		AddedBy: cmd.UserName,
+		AddedAt: time.Now().UTC(),
+	})
+	if err := config.SaveSubscriptions(cfg.Subscriptions, subs); err != nil {
+		log.Printf("Failed to save subscriptions: %v", err)
+		return "Sorry, the subscription couldn't be saved."
+	}
+	log.Printf("%s subscribed #%s to %s", cmd.UserName, cmd.ChannelName, feedURL)
+	return fmt.Sprintf("Subscribed this channel to %s (%s). New posts will show up after the next check.",
+		feedTitle(outcome.result.Items, feedURL), feedURL)
+}
+
+func (h *commandHandler) unsubscribe(cmd slack.Command, feedURL string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cfg, err := config.LoadConfig(h.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return "Sorry, the feed configuration can't be loaded right now."
+	}
+	removed, err := removeSubscription(cfg, cmd.ChannelName, feedURL)
+	if err != nil {
+		log.Printf("Failed to update subscriptions: %v", err)
+		return "Sorry, the subscription couldn't be removed."
+	}
+	if removed {
+		log.Printf("%s unsubscribed #%s from %s", cmd.UserName, cmd.ChannelName, feedURL)
+		return fmt.Sprintf("Unsubscribed this channel from %s.", feedURL)
+	}
+	if cfg.HasFeed(cmd.ChannelName, feedURL) {
+		return fmt.Sprintf("%s is set up in the config file, so it has to be removed there.", feedURL)
+	}
+	return fmt.Sprintf("This channel isn't subscribed to %s.", feedURL)
+}
+
+func (h *commandHandler) list(cmd slack.Command) string {
+	cfg, err := config.LoadMerged(h.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return "Sorry, the feed configuration can't be loaded right now."
+	}
+	ch := cfg.Channel(cmd.ChannelName)
+	if ch == nil {
+		return "This channel has no feeds. Use `/rss subscribe <feed-url>` to add one."
+	}
+	var b strings.Builder
+	b.WriteString("Feeds posted to this channel:")
+	for _, feed := range ch.Feeds {
+		b.WriteString("\n• " + feed.URL)
+		if !feed.IsEnabled() {
+			b.WriteString(" (paused)")
+		}
+	}
+	return b.String()
+}
+
+// checkFeedURL refuses URLs sent with a slash command that aren't http or
+// https, or whose host resolves to a non-public address. The fetch itself is
+// made with PublicOnly, which also covers redirects and later runs; this only
+// turns such URLs away with a clear reply before anything is fetched.
+func (h *commandHandler) checkFeedURL(ctx context.Context, feedURL string) error {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("missing host")
+	}
+	lookup := h.lookupIP
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupIP
+	}
+	ips, err := lookup(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !rss.IsPublic(ip) {
+			return fmt.Errorf("%s resolves to non-public address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+// commandURL returns the URL in a command argument. Slack may send links as
+// <url> or <url|label>.
+func commandURL(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if strings.HasPrefix(arg, "<") && strings.HasSuffix(arg, ">") {
+		arg = strings.TrimSuffix(strings.TrimPrefix(arg, "<"), ">")
+		arg, _, _ = strings.Cut(arg, "|")
+	}
+	return arg
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/config"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+	"slack-rss-feed-manager/state"
+)
+
+// fakeSlack sends signed slash command requests, the way Slack does.
+type fakeSlack struct {
+	t      *testing.T
+	url    string
+	secret string
+}
+
+func (f fakeSlack) command(channel, text string) (int, string) {
+	f.t.Helper()
+	body := url.Values{
+		"command":      {"/rss"},
+		"text":         {text},
+		"channel_id":   {"C1"},
+		"channel_name": {channel},
+		"user_id":      {"U1"},
+		"user_name":    {"sam"},
+	}.Encode()
+	req, err := http.NewRequest(http.MethodPost, f.url, strings.NewReader(body))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	slack.SignRequest(req, []byte(body), f.secret, time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var reply struct{ Text string }
+	json.NewDecoder(resp.Body).Decode(&reply)
+	return resp.StatusCode, reply.Text
+}
+
+// fakeLookup resolves the test hosts without DNS: intranet.example.com and
+// localhost to private addresses, every other name to a public one.
+func fakeLookup(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	switch host {
+	case "intranet.example.com":
+		return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+	case "localhost":
+		return []net.IP{net.ParseIP("127.0.0.1")}, nil
+	}
+	return []net.IP{net.ParseIP("93.184.216.34")}, nil
+}
+
+func TestSlashCommands(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	subsFile := filepath.Join(dir, "subscriptions.yaml")
+	err := os.WriteFile(configFile, []byte(`subscriptions: `+subsFile+`
+channels:
+  - slack_channel: general
+    feeds:
+      - https://example.com/config.xml
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockRSS := &mockRSSClient{items: []rss.FeedItem{{Title: "Hello", Link: "http://example.com/1", FeedTitle: "Example"}}}
+	handler := &commandHandler{configFile: configFile, signingSecret: "secret", rssClient: mockRSS, lookupIP: fakeLookup}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	slackFake := fakeSlack{t: t, url: server.URL, secret: "secret"}
+
+	expectReply := func(channel, text, want string) {
+		t.Helper()
+		status, reply := slackFake.command(channel, text)
+		if status != http.StatusOK || !strings.Contains(reply, want) {
+			t.Errorf("%q: got %d %q, want reply containing %q", text, status, reply, want)
+		}
+	}
+
+	expectReply("general", "subscribe <https://example.com/new.xml>", "Subscribed this channel to Example")
+	if !mockRSS.gotPublicOnly {
+		t.Error("Expected the subscribe check to fetch public addresses only")
+	}
+	expectReply("general", "subscribe https://example.com/new.xml", "already subscribed")
+	expectReply("general", "subscribe https://example.com/config.xml", "already subscribed")
+	expectReply("news", "subscribe https://example.com/new.xml", "Subscribed")
+	expectReply("general", "list", "https://example.com/config.xml\n• https://example.com/new.xml")
+	expectReply("random", "list", "no feeds")
+
+	cfg, err := config.LoadMerged(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.HasFeed("general", "https://example.com/new.xml") || !cfg.HasFeed("news", "https://example.com/new.xml") {
+		t.Errorf("Expected subscriptions in merged config, got %+v", cfg.Channels)
+	}
+
+	// Later runs fetch subscribed feeds with the same restriction
+	newsOnly := cfg
+	newsOnly.Channels = []config.Channel{*cfg.Channel("news")}
+	runRSS := &mockRSSClient{}
+	runState := state.State{Channels: map[string]state.ChannelState{}}
+	updateSubscriptions(context.Background(), newsOnly, &runState, runRSS)
+	processFeeds(context.Background(), newsOnly, &runState, &mockSlackClient{}, runRSS)
+	if len(runRSS.fetched) == 0 || !runRSS.gotPublicOnly {
+		t.Errorf("Expected subscribed feeds to be fetched from public addresses only, fetched %v", runRSS.fetched)
+	}
+
+	expectReply("general", "unsubscribe https://example.com/new.xml", "Unsubscribed")
+	expectReply("general", "unsubscribe https://example.com/new.xml", "isn't subscribed")
+	expectReply("general", "unsubscribe https://example.com/config.xml", "config file")
+	expectReply("general", "help", "Usage")
+
+	subs, err := config.LoadSubscriptions(subsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].Channel != "news" || subs[0].AddedBy != "sam" {
+		t.Errorf("Unexpected subscriptions %+v", subs)
+	}
+
+	mockRSS.err = errors.New("parse error at 10.0.0.5")
+	status, reply := slackFake.command("general", "subscribe https://example.com/broken")
+	if status != http.StatusOK || !strings.Contains(reply, "Couldn't read a feed") || strings.Contains(reply, "10.0.0.5") {
+		t.Errorf("Expected a generic error for a broken feed, got %d %q", status, reply)
+	}
+	mockRSS.err = nil
+
+	// The server only fetches public http and https addresses
+	for _, feedURL := range []string{
+		"file:///etc/passwd",
+		"http://localhost:8080/feed",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/feed",
+		"https://intranet.example.com/feed",
+	} {
+		expectReply("general", "subscribe "+feedURL, "public http or https")
+	}
+
+	expectReply("directmessage", "subscribe https://example.com/dm.xml", "direct messages")
+	expectReply("directmessage", "list", "direct messages")
+
+	// Requests that aren't signed with the secret are rejected
+	slackFake.secret = "wrong"
+	if status, _ := slackFake.command("general", "list"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a bad signature, got %d", status)
+	}
+}
@@ -2,18 +2,381 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/mail"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"slack-rss-feed-manager/filter"
+	"slack-rss-feed-manager/notify"
+	"slack-rss-feed-manager/render"
+	"slack-rss-feed-manager/slack"
+)
+
+// Defaults used when the fetch limits are not set.
+const (
+	DefaultConcurrency        = 4
+	DefaultPerHostConcurrency = 2
+	DefaultFeedTimeout        = 30 * time.Second
+	DefaultDaemonInterval     = time.Hour
+	DefaultMaxRetries         = 3
+	DefaultFailureThreshold   = 3
+	DefaultDigestItems        = 20
 )
 
 type Config struct {
-	Channels []Channel `yaml:"channels"`
+	// Concurrency limits how many feeds are fetched at once, and
+	// PerHostConcurrency how many of those may go to the same host.
+	Concurrency        int `yaml:"concurrency,omitempty"`
+	PerHostConcurrency int `yaml:"per_host_concurrency,omitempty"`
+	// Timeout bounds fetching all feeds in a run (zero means no limit), and
+	// FeedTimeout bounds each individual feed fetch.
+	Timeout     time.Duration `yaml:"timeout,omitempty"`
+	FeedTimeout time.Duration `yaml:"feed_timeout,omitempty"`
+	Daemon      Daemon        `yaml:"daemon,omitempty"`
+	Slack       SlackSettings `yaml:"slack,omitempty"`
+	Alerts      Alerts        `yaml:"alerts,omitempty"`
+	SMTP        SMTP          `yaml:"smtp,omitempty"`
+	// Subscriptions is the file holding feeds subscribed to from Slack, which
+	// LoadMerged adds to Channels.
+	Subscriptions string    `yaml:"subscriptions,omitempty"`
+	Channels      []Channel `yaml:"channels"`
+}
+
+// Daemon configures the long-running mode. Each cycle starts Interval after
+// the previous one finished, moved earlier or later by up to Jitter.
+type Daemon struct {
+	Interval time.Duration `yaml:"interval,omitempty"`
+	Jitter   time.Duration `yaml:"jitter,omitempty"`
+}
+
+// Alerts configures messages about feeds that keep failing. Without a
+// Channel no alerts are sent.
+type Alerts struct {
+	Channel string `yaml:"channel,omitempty"`
+	// FailureThreshold is how many fetches in a row must fail before alerting.
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+}
+
+// Threshold returns the failure threshold, falling back to the default.
+func (a Alerts) Threshold() int {
+	if a.FailureThreshold <= 0 {
+		return DefaultFailureThreshold
+	}
+	return a.FailureThreshold
+}
+
+// SMTP configures the mail server email targets are sent through.
+type SMTP struct {
+	Host string `yaml:"host,omitempty"`
+	// Port defaults to the submission port, 587, which uses STARTTLS.
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	// Password is expanded from the environment like target URLs.
+	Password string `yaml:"password,omitempty"`
+	// From is the sender address, optionally with a name.
+	From string `yaml:"from,omitempty"`
+}
+
+// Mailer returns the mail server to send through.
+func (s SMTP) Mailer() *notify.SMTP {
+	return &notify.SMTP{Host: s.Host, Port: s.Port, Username: s.Username, Password: os.ExpandEnv(s.Password)}
+}
+
+// SlackSettings controls how posts are sent to Slack.
+type SlackSettings struct {
+	// PostInterval is the minimum time between posts to the same channel.
+	PostInterval time.Duration `yaml:"post_interval,omitempty"`
+	// MaxRetries is how often a failed post is retried; 0 disables retries.
+	MaxRetries *int `yaml:"max_retries,omitempty"`
+}
+
+func (s SlackSettings) QueueOptions() slack.QueueOptions {
+	retries := DefaultMaxRetries
+	if s.MaxRetries != nil {
+		retries = *s.MaxRetries
+	}
+	return slack.QueueOptions{PostInterval: s.PostInterval, MaxRetries: retries}
+}
+
+// PollInterval returns the time between daemon cycles.
+func (d Daemon) PollInterval() time.Duration {
+	if d.Interval <= 0 {
+		return DefaultDaemonInterval
+	}
+	return d.Interval
 }
 
 type Channel struct {
-	SlackChannel string   `yaml:"slack_channel"`
-	Feeds        []string `yaml:"feeds"`
+	// SlackChannel names the channel. Unless Target says otherwise, items are
+	// posted to the Slack channel of that name.
+	SlackChannel string `yaml:"slack_channel"`
+	Target       Target `yaml:"target,omitempty"`
+	Feeds        []Feed `yaml:"feeds"`
+	// Template optionally replaces the default Block Kit message with a
+	// text/template rendered per item. Feeds can override it.
+	Template string `yaml:"template,omitempty"`
+	// Filters apply to every feed in the channel, in addition to the feed's own.
+	Filters Filters `yaml:"filters,omitempty"`
+	// Digest, when set, collects the channel's items and sends them together
+	// on a schedule.
+	Digest Digest `yaml:"digest,omitempty"`
+	// Thread, when set, posts a feed's items from the same run in one thread,
+	// started by the first item (ThreadFirst) or by a summary (ThreadSummary).
+	Thread string `yaml:"thread,omitempty"`
+	// Edits, when set, follows up on posted items whose title, link or
+	// summary changed later: the message is updated (EditsUpdate) or a reply
+	// in its thread notes the change (EditsReply).
+	Edits string `yaml:"edits,omitempty"`
+}
+
+// Ways of threading a feed's items.
+const (
+	ThreadFirst   = "first"
+	ThreadSummary = "summary"
+)
+
+// Ways of following up on edited items.
+const (
+	EditsUpdate = "update"
+	EditsReply  = "reply"
+)
+
+// Settings names the channel's settings besides its feeds that are set, in
+// the order they appear in the config.
+func (ch Channel) Settings() []string {
+	var set []string
+	if ch.Target.Type != "" || ch.Target.URL != "" || len(ch.Target.To) > 0 || ch.Target.Group {
+		set = append(set, "target")
+	}
+	if ch.Template != "" {
+		set = append(set, "template")
+	}
+	if !ch.Filters.IsZero() {
+		set = append(set, "filters")
+	}
+	if !ch.Digest.IsZero() {
+		set = append(set, "digest")
+	}
+	if ch.Thread != "" {
+		set = append(set, "thread")
+	}
+	if ch.Edits != "" {
+		set = append(set, "edits")
+	}
+	return set
+}
+
+// Batched reports whether the channel's items are held and sent together
+// rather than one at a time.
+func (ch Channel) Batched() bool {
+	return ch.Target.Group || !ch.Digest.IsZero()
+}
+
+// Digest schedules a daily or weekly message listing a channel's new items.
+type Digest struct {
+	// Every is daily or weekly.
+	Every string `yaml:"every,omitempty"`
+	// At is the time of day as HH:MM, 09:00 by default.
+	At string `yaml:"at,omitempty"`
+	// Day is the weekday of weekly digests, monday by default.
+	Day string `yaml:"day,omitempty"`
+	// Timezone is the IANA zone At and Day are in, the local zone by default.
+	Timezone string `yaml:"timezone,omitempty"`
+	// MaxItems caps how many items are listed; the rest are only counted.
+	MaxItems int `yaml:"max_items,omitempty"`
+}
+
+func (d Digest) IsZero() bool {
+	return d == Digest{}
+}
+
+// Limit returns the item cap, falling back to the default.
+func (d Digest) Limit() int {
+	if d.MaxItems <= 0 {
+		return DefaultDigestItems
+	}
+	return d.MaxItems
+}
+
+// Next returns the first scheduled time after t.
+func (d Digest) Next(t time.Time) time.Time {
+	// All settings were validated when the config was loaded
+	hour, minute, _ := d.clock()
+	weekday, _ := d.weekday()
+	loc, _ := d.location()
+
+	local := t.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	step := 1
+	if d.Every == "weekly" {
+		step = 7
+		next = next.AddDate(0, 0, (int(weekday)-int(next.Weekday())+7)%7)
+	}
+	for !next.After(t) {
+		next = next.AddDate(0, 0, step)
+	}
+	return next
+}
+
+func (d Digest) clock() (hour, minute int, err error) {
+	if d.At == "" {
+		return 9, 0, nil
+	}
+	at, err := time.Parse("15:04", d.At)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid digest time %q, expected HH:MM", d.At)
+	}
+	return at.Hour(), at.Minute(), nil
+}
+
+func (d Digest) weekday() (time.Weekday, error) {
+	if d.Day == "" {
+		return time.Monday, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(d.Day, day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid digest day %q", d.Day)
+}
+
+func (d Digest) location() (*time.Location, error) {
+	if d.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(d.Timezone)
+}
+
+func (d Digest) validate() error {
+	if d.Every != "daily" && d.Every != "weekly" {
+		return fmt.Errorf("digest every must be daily or weekly, got %q", d.Every)
+	}
+	if _, _, err := d.clock(); err != nil {
+		return err
+	}
+	if _, err := d.weekday(); err != nil {
+		return err
+	}
+	if d.Day != "" && d.Every != "weekly" {
+		return errors.New("digest day is only used by weekly digests")
+	}
+	if _, err := d.location(); err != nil {
+		return fmt.Errorf("invalid digest timezone: %w", err)
+	}
+	if d.MaxItems < 0 || d.MaxItems > slack.MaxDigestItems {
+		return fmt.Errorf("digest max_items must be between 0 (default %d) and %d", DefaultDigestItems, slack.MaxDigestItems)
+	}
+	return nil
+}
+
+// Target is where a channel's items are delivered: the Slack bot, a Slack
+// incoming webhook, a Discord, Teams or generic JSON webhook, or email.
+type Target struct {
+	// Type is one of notify.Types and defaults to the Slack bot.
+	Type string `yaml:"type,omitempty"`
+	// URL is the webhook URL. $VAR and ${VAR} are replaced from the
+	// environment so that secrets can be kept out of the config file.
+	URL string `yaml:"url,omitempty"`
+	// To lists the recipients of email targets.
+	To []string `yaml:"to,omitempty"`
+	// Group sends all of a run's new items in one email instead of one each.
+	Group bool `yaml:"group,omitempty"`
+}
+
+// WebhookURL returns the URL with environment variables expanded.
+func (t Target) WebhookURL() string {
+	return os.ExpandEnv(t.URL)
+}
+
+// Filters are include/exclude rules for items: keywords matched
+// case-insensitively, or regular expressions written as /pattern/.
+type Filters struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Compile parses the rules. They are validated when the config is loaded.
+func (f Filters) Compile() (filter.Set, error) {
+	return filter.Compile(f.Include, f.Exclude)
+}
+
+func (f Filters) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Feed is a feed subscription. In YAML it is either a bare URL or a mapping
+// with the URL and per-feed settings. State is keyed by URL, so renaming or
+// otherwise reconfiguring a feed keeps its history.
+type Feed struct {
+	URL string `yaml:"url"`
+	// Name replaces the feed's own title in messages.
+	Name     string            `yaml:"name,omitempty"`
+	Enabled  *bool             `yaml:"enabled,omitempty"`
+	Template string            `yaml:"template,omitempty"`
+	Filters  Filters           `yaml:"filters,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty"`
+	// PollInterval is the minimum time between checks of this feed. With
+	// AdaptivePoll the interval instead follows how often the feed publishes.
+	PollInterval time.Duration `yaml:"poll_interval,omitempty"`
+	AdaptivePoll PollBounds    `yaml:"adaptive_poll,omitempty"`
+	// FromSlack marks feeds added from the subscriptions file. Their URLs come
+	// from Slack users, so they are only fetched from public addresses.
+	FromSlack bool `yaml:"-"`
+}
+
+// PollBounds limits an adaptive poll interval.
+type PollBounds struct {
+	Min time.Duration `yaml:"min,omitempty"`
+	Max time.Duration `yaml:"max,omitempty"`
+}
+
+func (b PollBounds) IsZero() bool {
+	return b.Min == 0 && b.Max == 0
+}
+
+func (f *Feed) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*f = Feed{URL: node.Value}
+		return nil
+	}
+	type plain Feed
+	return node.Decode((*plain)(f))
+}
+
+// MarshalYAML writes feeds without settings in the short, bare URL form.
+func (f Feed) MarshalYAML() (interface{}, error) {
+	if f.isBare() {
+		return f.URL, nil
+	}
+	type plain Feed
+	return plain(f), nil
+}
+
+func (f Feed) isBare() bool {
+	return f.Name == "" && f.Enabled == nil && f.Template == "" && f.Filters.IsZero() &&
+		len(f.Headers) == 0 && f.Timeout == 0 && f.PollInterval == 0 && f.AdaptivePoll.IsZero()
+}
+
+// IsEnabled reports whether the feed should be fetched. Feeds are enabled
+// unless explicitly disabled.
+func (f Feed) IsEnabled() bool {
+	return f.Enabled == nil || *f.Enabled
+}
+
+// TemplateFor returns the message template for a feed in this channel, or ""
+// to use the default message format.
+func (ch Channel) TemplateFor(feed Feed) string {
+	if feed.Template != "" {
+		return feed.Template
+	}
+	return ch.Template
 }
 
 func LoadConfig(filePath string) (Config, error) {
@@ -35,10 +398,143 @@ func LoadConfig(filePath string) (Config, error) {
 	return cfg, nil
 }
 
+// Channel returns the channel with the given name, or nil if there is none.
+func (c *Config) Channel(name string) *Channel {
+	for i := range c.Channels {
+		if c.Channels[i].SlackChannel == name {
+			return &c.Channels[i]
+		}
+	}
+	return nil
+}
+
+// UsesSlackBot reports whether anything is posted through the Slack bot: a
+// channel without another target, or alerts.
+func (c *Config) UsesSlackBot() bool {
+	if c.Alerts.Channel != "" {
+		return true
+	}
+	for _, ch := range c.Channels {
+		if ch.Target.Type == "" || ch.Target.Type == notify.SlackBot {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFeed reports whether the named channel has the feed with url.
+func (c *Config) HasFeed(channel, url string) bool {
+	if ch := c.Channel(channel); ch != nil {
+		for _, f := range ch.Feeds {
+			if f.URL == url {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddFeed adds feed to the named channel, creating the channel if needed. It
+// returns false without changing anything if the channel already has the feed.
+func (c *Config) AddFeed(channel string, feed Feed) bool {
+	ch := c.Channel(channel)
+	if ch == nil {
+		c.Channels = append(c.Channels, Channel{SlackChannel: channel})
+		ch = &c.Channels[len(c.Channels)-1]
+	}
+	for _, f := range ch.Feeds {
+		if f.URL == feed.URL {
+			return false
+		}
+	}
+	ch.Feeds = append(ch.Feeds, feed)
+	return true
+}
+
+// RemoveFeed removes the feed with url from the named channel, and the channel
+// too once it has no feeds left, settings and all. It returns false if the
+// channel doesn't have the feed.
+func (c *Config) RemoveFeed(channel, url string) bool {
+	ch := c.Channel(channel)
+	if ch == nil {
+		return false
+	}
+	for i, f := range ch.Feeds {
+		if f.URL != url {
+			continue
+		}
+		ch.Feeds = append(ch.Feeds[:i:i], ch.Feeds[i+1:]...)
+		if len(ch.Feeds) == 0 {
+			for j := range c.Channels {
+				if c.Channels[j].SlackChannel == channel {
+					c.Channels = append(c.Channels[:j:j], c.Channels[j+1:]...)
+					break
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// FetchLimits returns the overall and per-host fetch concurrency, falling back
+// to the defaults for unset values.
+func (c Config) FetchLimits() (int, int) {
+	concurrency, perHost := c.Concurrency, c.PerHostConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if perHost <= 0 {
+		perHost = DefaultPerHostConcurrency
+	}
+	return concurrency, perHost
+}
+
+// FetchTimeout returns the timeout for fetching feed.
+func (c Config) FetchTimeout(feed Feed) time.Duration {
+	if feed.Timeout > 0 {
+		return feed.Timeout
+	}
+	if c.FeedTimeout <= 0 {
+		return DefaultFeedTimeout
+	}
+	return c.FeedTimeout
+}
+
 func validateConfig(cfg Config) error {
-	if len(cfg.Channels) == 0 {
+	// With a subscriptions file, every channel may come from Slack
+	if len(cfg.Channels) == 0 && cfg.Subscriptions == "" {
 		return errors.New("no channels configured")
 	}
+	if cfg.Concurrency < 0 {
+		return errors.New("concurrency cannot be negative")
+	}
+	if cfg.PerHostConcurrency < 0 {
+		return errors.New("per_host_concurrency cannot be negative")
+	}
+	if cfg.Timeout < 0 || cfg.FeedTimeout < 0 {
+		return errors.New("timeouts cannot be negative")
+	}
+	if cfg.Daemon.Interval < 0 || cfg.Daemon.Jitter < 0 {
+		return errors.New("daemon interval and jitter cannot be negative")
+	}
+	if cfg.Daemon.Jitter >= cfg.Daemon.PollInterval() {
+		return errors.New("daemon jitter must be less than the interval")
+	}
+	if cfg.Alerts.FailureThreshold < 0 {
+		return errors.New("alerts failure_threshold cannot be negative")
+	}
+	if cfg.Slack.PostInterval < 0 || (cfg.Slack.MaxRetries != nil && *cfg.Slack.MaxRetries < 0) {
+		return errors.New("slack post_interval and max_retries cannot be negative")
+	}
+	if cfg.SMTP.Port < 0 {
+		return errors.New("smtp port cannot be negative")
+	}
+	if cfg.SMTP.From != "" {
+		if _, err := mail.ParseAddress(cfg.SMTP.From); err != nil {
+			return fmt.Errorf("invalid smtp from address: %w", err)
+		}
+	}
 
 	for _, ch := range cfg.Channels {
 		if ch.SlackChannel == "" {
@@ -47,10 +543,136 @@ func validateConfig(cfg Config) error {
 		if len(ch.Feeds) == 0 {
 			return errors.New("no feeds configured for channel " + ch.SlackChannel)
 		}
+		if err := validateTarget(ch); err != nil {
+			return fmt.Errorf("%w for channel %s", err, ch.SlackChannel)
+		}
+		if ch.Target.Type == notify.Email && (cfg.SMTP.Host == "" || cfg.SMTP.From == "") {
+			return fmt.Errorf("channel %s sends email but smtp host and from are not set", ch.SlackChannel)
+		}
+		if ch.Thread != "" {
+			if ch.Thread != ThreadFirst && ch.Thread != ThreadSummary {
+				return fmt.Errorf("thread must be %s or %s for channel %s, got %q", ThreadFirst, ThreadSummary, ch.SlackChannel, ch.Thread)
+			}
+			// Replies need the timestamp of the parent, which only the bot gets back
+			if ch.Target.Type != "" && ch.Target.Type != notify.SlackBot {
+				return fmt.Errorf("threads are not supported by %s targets for channel %s", ch.Target.Type, ch.SlackChannel)
+			}
+			if ch.Batched() {
+				return fmt.Errorf("channel %s cannot use threads together with a digest or group", ch.SlackChannel)
+			}
+		}
+		if ch.Edits != "" {
+			if ch.Edits != EditsUpdate && ch.Edits != EditsReply {
+				return fmt.Errorf("edits must be %s or %s for channel %s, got %q", EditsUpdate, EditsReply, ch.SlackChannel, ch.Edits)
+			}
+			// Only messages posted by the bot can be found again
+			if ch.Target.Type != "" && ch.Target.Type != notify.SlackBot {
+				return fmt.Errorf("edits are not supported by %s targets for channel %s", ch.Target.Type, ch.SlackChannel)
+			}
+			if ch.Batched() {
+				return fmt.Errorf("channel %s cannot follow edits together with a digest or group", ch.SlackChannel)
+			}
+		}
+		if !ch.Digest.IsZero() {
+			if err := ch.Digest.validate(); err != nil {
+				return fmt.Errorf("%w for channel %s", err, ch.SlackChannel)
+			}
+			if ch.Target.Group {
+				return fmt.Errorf("channel %s cannot set both a digest and target group", ch.SlackChannel)
+			}
+			if !slices.Contains([]string{"", notify.SlackBot, notify.SlackWebhook, notify.Email}, ch.Target.Type) {
+				return fmt.Errorf("digests are not supported by %s targets for channel %s", ch.Target.Type, ch.SlackChannel)
+			}
+		}
+		if ch.Template != "" {
+			if _, err := render.Parse(ch.SlackChannel, ch.Template); err != nil {
+				return fmt.Errorf("invalid template for channel %s: %w", ch.SlackChannel, err)
+			}
+		}
+		if _, err := ch.Filters.Compile(); err != nil {
+			return fmt.Errorf("invalid filters for channel %s: %w", ch.SlackChannel, err)
+		}
+		seen := make(map[string]bool, len(ch.Feeds))
 		for _, feed := range ch.Feeds {
-			if feed == "" {
-				return errors.New("feed URL cannot be empty in channel " + ch.SlackChannel)
+			if err := validateFeed(feed); err != nil {
+				return fmt.Errorf("%w in channel %s", err, ch.SlackChannel)
 			}
+			if seen[feed.URL] {
+				return fmt.Errorf("feed %s is listed more than once in channel %s", feed.URL, ch.SlackChannel)
+			}
+			seen[feed.URL] = true
+		}
+	}
+	return nil
+}
+
+func validateTarget(ch Channel) error {
+	t := ch.Target
+	if t.Type != "" && !slices.Contains(notify.Types, t.Type) {
+		return fmt.Errorf("unknown target type %q (want one of %s)", t.Type, strings.Join(notify.Types, ", "))
+	}
+	if t.URL != "" && !notify.IsWebhook(t.Type) {
+		return errors.New("target url is only used by webhook targets")
+	}
+	if t.URL == "" && notify.IsWebhook(t.Type) {
+		return errors.New("target url cannot be empty")
+	}
+	if t.Type == notify.Email {
+		if len(t.To) == 0 {
+			return errors.New("email target needs at least one recipient in to")
+		}
+		for _, addr := range t.To {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return fmt.Errorf("invalid email recipient %q", addr)
+			}
+		}
+	} else if len(t.To) > 0 || t.Group {
+		return errors.New("target to and group are only used by email targets")
+	}
+	if t.Type == "" || t.Type == notify.SlackBot || t.Type == notify.SlackWebhook {
+		return nil
+	}
+	// Templates render Slack mrkdwn, which other services don't understand
+	if ch.Template != "" {
+		return fmt.Errorf("templates are not supported by %s targets", t.Type)
+	}
+	for _, feed := range ch.Feeds {
+		if feed.Template != "" {
+			return fmt.Errorf("templates are not supported by %s targets (feed %s)", t.Type, feed.URL)
+		}
+	}
+	return nil
+}
+
+func validateFeed(feed Feed) error {
+	if feed.URL == "" {
+		return errors.New("feed URL cannot be empty")
+	}
+	if feed.Timeout < 0 {
+		return fmt.Errorf("timeout for feed %s cannot be negative", feed.URL)
+	}
+	if feed.PollInterval < 0 {
+		return fmt.Errorf("poll_interval for feed %s cannot be negative", feed.URL)
+	}
+	if !feed.AdaptivePoll.IsZero() {
+		if feed.PollInterval != 0 {
+			return fmt.Errorf("feed %s cannot set both poll_interval and adaptive_poll", feed.URL)
+		}
+		if feed.AdaptivePoll.Min <= 0 || feed.AdaptivePoll.Max < feed.AdaptivePoll.Min {
+			return fmt.Errorf("adaptive_poll for feed %s needs 0 < min <= max", feed.URL)
+		}
+	}
+	for name := range feed.Headers {
+		if name == "" {
+			return fmt.Errorf("header name cannot be empty for feed %s", feed.URL)
+		}
+	}
+	if _, err := feed.Filters.Compile(); err != nil {
+		return fmt.Errorf("invalid filters for feed %s: %w", feed.URL, err)
+	}
+	if feed.Template != "" {
+		if _, err := render.Parse(feed.URL, feed.Template); err != nil {
+			return fmt.Errorf("invalid template for feed %s: %w", feed.URL, err)
 		}
 	}
 	return nil
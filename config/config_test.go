@@ -2,7 +2,14 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"slack-rss-feed-manager/slack"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -102,4 +109,749 @@ func TestConfigValidation(t *testing.T) {
 			t.Error("Expected error for empty channel name, got nil")
 		}
 	})
+	t.Run("negative concurrency", func(t *testing.T) {
+		content := `concurrency: -1
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`
+
+		tmpfile, err := os.CreateTemp("", "config*.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tmpfile.Name())
+
+		if _, err := tmpfile.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tmpfile.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = LoadConfig(tmpfile.Name())
+		if err == nil {
+			t.Error("Expected error for negative concurrency, got nil")
+		}
+	})
+}
+
+func TestFetchLimits(t *testing.T) {
+	concurrency, perHost := Config{}.FetchLimits()
+	if concurrency != DefaultConcurrency || perHost != DefaultPerHostConcurrency {
+		t.Errorf("Expected defaults %d/%d, got %d/%d", DefaultConcurrency, DefaultPerHostConcurrency, concurrency, perHost)
+	}
+
+	concurrency, perHost = Config{Concurrency: 8, PerHostConcurrency: 1}.FetchLimits()
+	if concurrency != 8 || perHost != 1 {
+		t.Errorf("Expected 8/1, got %d/%d", concurrency, perHost)
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name: "valid channel and feed templates",
+			content: `channels:
+  - slack_channel: releases
+    template: "<{{ .Link }}|{{ escape .Title }}>"
+    feeds:
+      - https://example.com/feed.xml
+      - url: https://example.com/other.xml
+        template: "{{ .Title }}"`,
+		},
+		{
+			name: "invalid channel template",
+			content: `channels:
+  - slack_channel: releases
+    template: "{{ .Title "
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "unknown field",
+			content: `channels:
+  - slack_channel: releases
+    template: "{{ .Body }}"
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "invalid feed template",
+			content: `channels:
+  - slack_channel: releases
+    feeds:
+      - url: https://example.com/feed.xml
+        template: "{{ .Title | shout }}"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("feed template overrides channel", func(t *testing.T) {
+		ch := Channel{Template: "channel"}
+		if got := ch.TemplateFor(Feed{URL: "https://example.com/feed.xml"}); got != "channel" {
+			t.Errorf("Expected channel template, got %q", got)
+		}
+		if got := ch.TemplateFor(Feed{URL: "https://example.com/other.xml", Template: "feed"}); got != "feed" {
+			t.Errorf("Expected feed template, got %q", got)
+		}
+	})
+}
+
+func TestFeedEntries(t *testing.T) {
+	content := `feed_timeout: 20s
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/plain.xml
+      - url: https://example.com/private.xml
+        name: Private Blog
+        enabled: false
+        timeout: 1m
+        headers:
+          Authorization: Bearer secret`
+
+	cfg, err := LoadConfig(writeConfig(t, content))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	feeds := cfg.Channels[0].Feeds
+	if len(feeds) != 2 {
+		t.Fatalf("Expected 2 feeds, got %d", len(feeds))
+	}
+	if feeds[0].URL != "https://example.com/plain.xml" || !feeds[0].IsEnabled() {
+		t.Errorf("Unexpected plain feed %+v", feeds[0])
+	}
+	private := feeds[1]
+	if private.URL != "https://example.com/private.xml" || private.Name != "Private Blog" {
+		t.Errorf("Unexpected structured feed %+v", private)
+	}
+	if private.IsEnabled() {
+		t.Error("Expected feed to be disabled")
+	}
+	if private.Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("Expected Authorization header, got %v", private.Headers)
+	}
+	if got := cfg.FetchTimeout(private); got != time.Minute {
+		t.Errorf("Expected feed timeout 1m, got %v", got)
+	}
+	if got := cfg.FetchTimeout(feeds[0]); got != 20*time.Second {
+		t.Errorf("Expected global feed timeout 20s, got %v", got)
+	}
+
+	t.Run("marshals bare feeds as strings", func(t *testing.T) {
+		data, err := yaml.Marshal(cfg.Channels[0].Feeds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roundTrip []Feed
+		if err := yaml.Unmarshal(data, &roundTrip); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), "- https://example.com/plain.xml\n") {
+			t.Errorf("Expected bare URL entry, got:\n%s", data)
+		}
+		if roundTrip[1].Name != "Private Blog" || roundTrip[1].IsEnabled() || roundTrip[1].Timeout != time.Minute {
+			t.Errorf("Structured feed did not round-trip: %+v", roundTrip[1])
+		}
+	})
+
+	t.Run("duplicate feed", func(t *testing.T) {
+		content := `channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml
+      - url: https://example.com/feed.xml
+        name: Again`
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Error("Expected error for duplicate feed, got nil")
+		}
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		content := `channels:
+  - slack_channel: test-channel
+    feeds:
+      - name: No URL`
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Error("Expected error for feed without URL, got nil")
+		}
+	})
+}
+
+func TestFilters(t *testing.T) {
+	t.Run("channel and feed filters", func(t *testing.T) {
+		content := `channels:
+  - slack_channel: engineering
+    filters:
+      exclude: [hiring, webinar]
+    feeds:
+      - https://example.com/plain.xml
+      - url: https://example.com/big-co.xml
+        filters:
+          include: ["/(?i)postmortem|outage/"]`
+
+		cfg, err := LoadConfig(writeConfig(t, content))
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		ch := cfg.Channels[0]
+		if len(ch.Filters.Exclude) != 2 {
+			t.Errorf("Expected 2 channel exclude rules, got %v", ch.Filters.Exclude)
+		}
+		if !ch.Feeds[0].Filters.IsZero() {
+			t.Errorf("Expected plain feed to have no filters, got %+v", ch.Feeds[0].Filters)
+		}
+		if len(ch.Feeds[1].Filters.Include) != 1 {
+			t.Errorf("Expected 1 feed include rule, got %v", ch.Feeds[1].Filters.Include)
+		}
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		content := `channels:
+  - slack_channel: engineering
+    feeds:
+      - url: https://example.com/feed.xml
+        filters:
+          exclude: ["/[unclosed/"]`
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Error("Expected error for invalid regex, got nil")
+		}
+	})
+}
+
+func TestDaemonSettings(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `daemon:
+  interval: 15m
+  jitter: 1m
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Daemon.PollInterval() != 15*time.Minute || cfg.Daemon.Jitter != time.Minute {
+		t.Errorf("Unexpected daemon settings %+v", cfg.Daemon)
+	}
+
+	_, err = LoadConfig(writeConfig(t, `daemon:
+  interval: 1m
+  jitter: 2m
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`))
+	if err == nil {
+		t.Error("Expected error for jitter larger than interval, got nil")
+	}
+}
+
+func TestPollSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		feed        string
+		expectError bool
+	}{
+		{"fixed interval", "poll_interval: 24h", false},
+		{"adaptive", "adaptive_poll: {min: 1h, max: 168h}", false},
+		{"negative interval", "poll_interval: -1h", true},
+		{"both", "poll_interval: 1h\n        adaptive_poll: {min: 1h, max: 2h}", true},
+		{"min above max", "adaptive_poll: {min: 2h, max: 1h}", true},
+		{"missing min", "adaptive_poll: {max: 1h}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `channels:
+  - slack_channel: test-channel
+    feeds:
+      - url: https://example.com/feed.xml
+        ` + tt.feed
+			_, err := LoadConfig(writeConfig(t, content))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSlackSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    string
+		expected    slack.QueueOptions
+		expectError bool
+	}{
+		{"defaults", "", slack.QueueOptions{MaxRetries: DefaultMaxRetries}, false},
+		{"custom", "slack:\n  post_interval: 2s\n  max_retries: 5\n", slack.QueueOptions{PostInterval: 2 * time.Second, MaxRetries: 5}, false},
+		{"retries disabled", "slack:\n  max_retries: 0\n", slack.QueueOptions{}, false},
+		{"negative retries", "slack:\n  max_retries: -1\n", slack.QueueOptions{}, true},
+		{"negative interval", "slack:\n  post_interval: -1s\n", slack.QueueOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, tt.settings+`channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := cfg.Slack.QueueOptions(); got != tt.expected {
+				t.Errorf("QueueOptions() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRemoveFeed(t *testing.T) {
+	var cfg Config
+	cfg.AddFeed("general", Feed{URL: "https://example.com/a.xml"})
+	cfg.AddFeed("general", Feed{URL: "https://example.com/b.xml"})
+
+	if cfg.RemoveFeed("general", "https://example.com/missing.xml") || cfg.RemoveFeed("missing", "https://example.com/a.xml") {
+		t.Error("Expected RemoveFeed to report feeds that aren't there")
+	}
+	if !cfg.RemoveFeed("general", "https://example.com/a.xml") {
+		t.Fatal("Expected feed to be removed")
+	}
+	if feeds := cfg.Channels[0].Feeds; len(feeds) != 1 || feeds[0].URL != "https://example.com/b.xml" {
+		t.Errorf("Unexpected feeds %+v", feeds)
+	}
+	cfg.RemoveFeed("general", "https://example.com/b.xml")
+	if len(cfg.Channels) != 0 {
+		t.Errorf("Expected empty channel to be removed, got %+v", cfg.Channels)
+	}
+}
+
+func TestChannelSettings(t *testing.T) {
+	if settings := (Channel{SlackChannel: "general", Feeds: []Feed{{URL: "https://example.com/a.xml"}}}).Settings(); len(settings) != 0 {
+		t.Errorf("Expected no settings, got %v", settings)
+	}
+	ch := Channel{
+		SlackChannel: "general",
+		Target:       Target{Type: "email", To: []string{"team@example.com"}},
+		Digest:       Digest{Every: "daily"},
+		Edits:        EditsReply,
+	}
+	if settings := strings.Join(ch.Settings(), ", "); settings != "target, digest, edits" {
+		t.Errorf("Settings() = %q", settings)
+	}
+}
+
+func TestAlertSettings(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `alerts:
+  channel: rss-admin
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Alerts.Channel != "rss-admin" || cfg.Alerts.Threshold() != DefaultFailureThreshold {
+		t.Errorf("Unexpected alert settings %+v", cfg.Alerts)
+	}
+
+	_, err = LoadConfig(writeConfig(t, `alerts:
+  failure_threshold: -1
+channels:
+  - slack_channel: test-channel
+    feeds:
+      - https://example.com/feed.xml`))
+	if err == nil {
+		t.Error("Expected error for negative failure threshold, got nil")
+	}
+}
+
+func TestUsesSlackBot(t *testing.T) {
+	webhook := Channel{SlackChannel: "hooks", Target: Target{Type: "discord", URL: "https://discord.example/hook"}}
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"default target", Config{Channels: []Channel{webhook, {SlackChannel: "general"}}}, true},
+		{"slack target", Config{Channels: []Channel{{SlackChannel: "general", Target: Target{Type: "slack"}}}}, true},
+		{"webhooks only", Config{Channels: []Channel{webhook}}, false},
+		{"alerts", Config{Channels: []Channel{webhook}, Alerts: Alerts{Channel: "rss-admin"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.UsesSlackBot(); got != tt.want {
+			t.Errorf("%s: UsesSlackBot() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name: "discord webhook",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: discord
+      url: https://discord.com/api/webhooks/1/abc
+    feeds:
+      - https://example.com/feed.xml`,
+		},
+		{
+			name: "slack webhook with template",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: slack_webhook
+      url: $RELEASES_WEBHOOK
+    template: "{{ .Title }}"
+    feeds:
+      - https://example.com/feed.xml`,
+		},
+		{
+			name: "unknown type",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: pager
+      url: https://example.com/hook
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "webhook without url",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: teams
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "slack bot with url",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      url: https://example.com/hook
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "grouped email",
+			content: `smtp:
+  host: smtp.example.com
+  username: rss
+  password: $SMTP_PASSWORD
+  from: RSS <rss@example.com>
+channels:
+  - slack_channel: releases
+    target:
+      type: email
+      to: [team@example.com, Jane <jane@example.com>]
+      group: true
+    feeds:
+      - https://example.com/feed.xml`,
+		},
+		{
+			name: "email without smtp",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: email
+      to: [team@example.com]
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "email without recipients",
+			content: `smtp:
+  host: smtp.example.com
+  from: rss@example.com
+channels:
+  - slack_channel: releases
+    target:
+      type: email
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "invalid recipient",
+			content: `smtp:
+  host: smtp.example.com
+  from: rss@example.com
+channels:
+  - slack_channel: releases
+    target:
+      type: email
+      to: [not an address]
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "group for slack",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      group: true
+    feeds:
+      - https://example.com/feed.xml`,
+			expectError: true,
+		},
+		{
+			name: "feed template for discord",
+			content: `channels:
+  - slack_channel: releases
+    target:
+      type: discord
+      url: https://discord.com/api/webhooks/1/abc
+    feeds:
+      - url: https://example.com/feed.xml
+        template: "{{ .Title }}"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("smtp password from environment", func(t *testing.T) {
+		t.Setenv("SMTP_PASSWORD", "hunter2")
+		mailer := SMTP{Host: "smtp.example.com", Username: "rss", Password: "$SMTP_PASSWORD"}.Mailer()
+		if mailer.Password != "hunter2" || mailer.Host != "smtp.example.com" {
+			t.Errorf("Unexpected mailer %+v", mailer)
+		}
+	})
+
+	t.Run("url from environment", func(t *testing.T) {
+		t.Setenv("RELEASES_WEBHOOK", "https://hooks.slack.com/services/T/B/x")
+		target := Target{Type: "slack_webhook", URL: "${RELEASES_WEBHOOK}"}
+		if got := target.WebhookURL(); got != "https://hooks.slack.com/services/T/B/x" {
+			t.Errorf("Expected expanded URL, got %q", got)
+		}
+	})
+}
+
+func TestDigestSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		digest      string
+		expectError bool
+	}{
+		{name: "daily", digest: "{every: daily, at: \"17:30\", timezone: Europe/Berlin, max_items: 10}"},
+		{name: "weekly", digest: "{every: weekly, day: Friday}"},
+		{name: "unknown schedule", digest: "{every: hourly}", expectError: true},
+		{name: "invalid time", digest: "{every: daily, at: \"25:00\"}", expectError: true},
+		{name: "invalid day", digest: "{every: weekly, day: someday}", expectError: true},
+		{name: "day for daily", digest: "{every: daily, day: monday}", expectError: true},
+		{name: "invalid timezone", digest: "{every: daily, timezone: Mars/Olympus}", expectError: true},
+		{name: "too many items", digest: "{every: daily, max_items: 500}", expectError: true},
+		{name: "default items", digest: "{every: daily, max_items: 0}"},
+		{name: "negative items", digest: "{every: daily, max_items: -1}", expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, `channels:
+  - slack_channel: low-priority
+    digest: `+tt.digest+`
+    feeds:
+      - https://example.com/feed.xml`))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	_, err := LoadConfig(writeConfig(t, `channels:
+  - slack_channel: low-priority
+    target:
+      type: discord
+      url: https://discord.com/api/webhooks/1/abc
+    digest:
+      every: daily
+    feeds:
+      - https://example.com/feed.xml`))
+	if err == nil {
+		t.Error("Expected error for a Discord digest")
+	}
+}
+
+func TestDigestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// A Wednesday
+	now := time.Date(2025, 7, 23, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		digest Digest
+		after  time.Time
+		want   time.Time
+	}{
+		{
+			name:   "later today",
+			digest: Digest{Every: "daily", At: "17:30", Timezone: "UTC"},
+			after:  now,
+			want:   time.Date(2025, 7, 23, 17, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "tomorrow",
+			digest: Digest{Every: "daily", Timezone: "UTC"},
+			after:  now,
+			want:   time.Date(2025, 7, 24, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "exactly at the time",
+			digest: Digest{Every: "daily", At: "12:00", Timezone: "UTC"},
+			after:  now,
+			want:   time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "time zone",
+			digest: Digest{Every: "daily", At: "13:00", Timezone: "Europe/Berlin"},
+			after:  now,
+			want:   time.Date(2025, 7, 24, 13, 0, 0, 0, berlin),
+		},
+		{
+			name:   "weekly",
+			digest: Digest{Every: "weekly", Day: "friday", Timezone: "UTC"},
+			after:  now,
+			want:   time.Date(2025, 7, 25, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "weekly on the day, after the time",
+			digest: Digest{Every: "weekly", Day: "Wednesday", At: "08:00", Timezone: "UTC"},
+			after:  now,
+			want:   time.Date(2025, 7, 30, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.digest.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if limit := (Digest{Every: "daily"}).Limit(); limit != DefaultDigestItems {
+		t.Errorf("Limit() = %d, want the default %d", limit, DefaultDigestItems)
+	}
+}
+
+func TestThreadSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		channel     string
+		expectError bool
+	}{
+		{name: "first", channel: "thread: first"},
+		{name: "summary", channel: "thread: summary"},
+		{name: "unknown", channel: "thread: all", expectError: true},
+		{name: "webhook", channel: "thread: first\n    target: {type: slack_webhook, url: https://hooks.slack.com/x}", expectError: true},
+		{name: "digest", channel: "thread: first\n    digest: {every: daily}", expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, `channels:
+  - slack_channel: releases
+    `+tt.channel+`
+    feeds:
+      - https://example.com/feed.xml`))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEditSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		channel     string
+		expectError bool
+	}{
+		{name: "update", channel: "edits: update"},
+		{name: "reply", channel: "edits: reply\n    thread: summary"},
+		{name: "unknown", channel: "edits: delete", expectError: true},
+		{name: "webhook", channel: "edits: update\n    target: {type: discord, url: https://discord.com/api/webhooks/x}", expectError: true},
+		{name: "digest", channel: "edits: reply\n    digest: {every: weekly}", expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, `channels:
+  - slack_channel: releases
+    `+tt.channel+`
+    feeds:
+      - https://example.com/feed.xml`))
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
 }
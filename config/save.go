@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveConfig validates cfg and writes it to filePath. When the file already
+// exists, its comments, key order and styles are kept for everything that is
+// still in cfg, so hand-written notes survive edits made by commands.
+func SaveConfig(filePath string, cfg Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+	var updated yaml.Node
+	if err := updated.Encode(cfg); err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&updated}}
+	data, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var existing yaml.Node
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return err
+	}
+	if existing.Kind == yaml.DocumentNode && len(existing.Content) == 1 {
+		existing.Content[0] = mergeNode(existing.Content[0], &updated)
+		doc = &existing
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, buf.Bytes())
+}
+
+// writeFileAtomic replaces filePath with data through a temporary file in the
+// same directory, so a crash or a full disk never leaves a truncated file.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Cleans up after a failure; after the rename the temp file is gone
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// mergeNode returns updated with the comments and layout of old carried over.
+// Mapping keys keep their old order, with new keys appended, and sequence
+// items are matched by their identity (see nodeKey) rather than position.
+func mergeNode(old, updated *yaml.Node) *yaml.Node {
+	if old.Kind != updated.Kind {
+		copyComments(updated, old)
+		return updated
+	}
+
+	switch updated.Kind {
+	case yaml.MappingNode:
+		var content []*yaml.Node
+		used := make(map[string]bool)
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			key := old.Content[i]
+			if value := mappingValue(updated, key.Value); value != nil {
+				content = append(content, key, mergeNode(old.Content[i+1], value))
+				used[key.Value] = true
+			}
+		}
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			if !used[updated.Content[i].Value] {
+				content = append(content, updated.Content[i], updated.Content[i+1])
+			}
+		}
+		old.Content = content
+		return old
+
+	case yaml.SequenceNode:
+		previous := make(map[string]*yaml.Node)
+		for _, item := range old.Content {
+			if key := nodeKey(item); key != "" {
+				previous[key] = item
+			}
+		}
+		content := make([]*yaml.Node, 0, len(updated.Content))
+		for _, item := range updated.Content {
+			if o, ok := previous[nodeKey(item)]; ok && nodeKey(item) != "" {
+				content = append(content, mergeNode(o, item))
+			} else {
+				content = append(content, item)
+			}
+		}
+		old.Content = content
+		return old
+
+	case yaml.ScalarNode:
+		if old.Value == updated.Value || sameDuration(old.Value, updated.Value) {
+			return old
+		}
+		copyComments(updated, old)
+		return updated
+
+	default:
+		copyComments(updated, old)
+		return updated
+	}
+}
+
+// nodeKey identifies a sequence item across edits: a feed by its URL, written
+// either as a bare string or under url, and a channel by its name.
+func nodeKey(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value
+	case yaml.MappingNode:
+		for _, name := range []string{"url", "slack_channel"} {
+			if value := mappingValue(n, name); value != nil && value.Kind == yaml.ScalarNode {
+				return value.Value
+			}
+		}
+	}
+	return ""
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sameDuration reports whether a and b are equal durations written
+// differently, such as 1h and the encoder's 1h0m0s.
+func sameDuration(a, b string) bool {
+	da, errA := time.ParseDuration(a)
+	db, errB := time.ParseDuration(b)
+	return errA == nil && errB == nil && da == db
+}
+
+func copyComments(to, from *yaml.Node) {
+	to.HeadComment = from.HeadComment
+	to.LineComment = from.LineComment
+	to.FootComment = from.FootComment
+}
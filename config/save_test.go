@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveConfigKeepsComments(t *testing.T) {
+	path := writeConfig(t, `# Feeds for the team
+channels:
+  # Engineering news
+  - slack_channel: engineering
+    feeds:
+      - https://example.com/a.xml # the good one
+      # Paused while they fix their feed
+      - url: https://example.com/b.xml
+        enabled: false
+        adaptive_poll: {min: 1h, max: 168h}
+  - slack_channel: random
+    feeds:
+      - https://example.com/c.xml
+daemon:
+  interval: 1h # hourly is plenty
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddFeed("engineering", Feed{URL: "https://example.com/new.xml"})
+	cfg.RemoveFeed("random", "https://example.com/c.xml")
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Feeds for the team
+channels:
+  # Engineering news
+  - slack_channel: engineering
+    feeds:
+      - https://example.com/a.xml # the good one
+      # Paused while they fix their feed
+      - url: https://example.com/b.xml
+        enabled: false
+        adaptive_poll: {min: 1h, max: 168h}
+      - https://example.com/new.xml
+daemon:
+  interval: 1h # hourly is plenty
+`
+	if string(data) != want {
+		t.Errorf("Saved config:\n%s\nwant:\n%s", data, want)
+	}
+
+	// The result still loads to the same config
+	saved, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Channels) != 1 || len(saved.Channels[0].Feeds) != 3 || saved.Daemon.Interval != time.Hour {
+		t.Errorf("Unexpected config after save: %+v", saved)
+	}
+}
+
+func TestSaveConfigNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	var cfg Config
+	cfg.AddFeed("general", Feed{URL: "https://example.com/feed.xml"})
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "- slack_channel: general") {
+		t.Errorf("Unexpected config:\n%s", data)
+	}
+	// The file is replaced through a temporary file that doesn't stay behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the config file, got %v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v (%v)", info.Mode(), err)
+	}
+
+	if err := SaveConfig(path, Config{}); err == nil {
+		t.Error("Expected error saving an invalid config")
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Subscription is a feed subscribed to from Slack rather than listed in the
+// config file.
+type Subscription struct {
+	Channel string    `yaml:"channel"`
+	URL     string    `yaml:"url"`
+	AddedBy string    `yaml:"added_by,omitempty"`
+	AddedAt time.Time `yaml:"added_at"`
+}
+
+// LoadSubscriptions reads a subscriptions file. A missing file has none.
+func LoadSubscriptions(filePath string) ([]Subscription, error) {
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var subs []Subscription
+	if err := yaml.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("invalid subscriptions file %s: %w", filePath, err)
+	}
+	return subs, nil
+}
+
+// SaveSubscriptions replaces the subscriptions file. The new contents are
+// written to a temporary file first, so readers never see a partial file.
+func SaveSubscriptions(filePath string, subs []Subscription) error {
+	data, err := yaml.Marshal(subs)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, data)
+}
+
+// LoadMerged loads the config file and adds the feeds from its subscriptions
+// file, if it names one. Feeds already in a channel's config are not added
+// again, so their settings in the config file win.
+func LoadMerged(filePath string) (Config, error) {
+	cfg, err := LoadConfig(filePath)
+	if err != nil || cfg.Subscriptions == "" {
+		return cfg, err
+	}
+	subs, err := LoadSubscriptions(cfg.Subscriptions)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, sub := range subs {
+		cfg.AddFeed(sub.Channel, Feed{URL: sub.URL, FromSlack: true})
+	}
+	if err := validateConfig(cfg); err != nil {
+		return Config{}, fmt.Errorf("with subscriptions from %s: %w", cfg.Subscriptions, err)
+	}
+	return cfg, nil
+}
+
+// RemoveSubscription removes the subscription of channel to url, and reports
+// whether there was one.
+func RemoveSubscription(subs []Subscription, channel, url string) ([]Subscription, bool) {
+	for i, sub := range subs {
+		if sub.Channel == channel && sub.URL == url {
+			return append(subs[:i:i], subs[i+1:]...), true
+		}
+	}
+	return subs, false
+}
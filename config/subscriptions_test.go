@@ -0,0 +1,81 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMerged(t *testing.T) {
+	dir := t.TempDir()
+	subsFile := filepath.Join(dir, "subscriptions.yaml")
+	path := writeConfig(t, `subscriptions: `+subsFile+`
+channels:
+  - slack_channel: general
+    feeds:
+      - url: https://example.com/a.xml
+        name: A`)
+
+	// No subscriptions file yet
+	cfg, err := LoadMerged(path)
+	if err != nil {
+		t.Fatalf("LoadMerged() error = %v", err)
+	}
+	if len(cfg.Channels) != 1 || len(cfg.Channels[0].Feeds) != 1 {
+		t.Errorf("Unexpected config %+v", cfg.Channels)
+	}
+
+	subs := []Subscription{
+		{Channel: "general", URL: "https://example.com/a.xml", AddedAt: time.Now()},
+		{Channel: "general", URL: "https://example.com/b.xml", AddedAt: time.Now()},
+		{Channel: "news", URL: "https://example.com/c.xml", AddedBy: "sam", AddedAt: time.Now()},
+	}
+	if err := SaveSubscriptions(subsFile, subs); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadMerged(path)
+	if err != nil {
+		t.Fatalf("LoadMerged() error = %v", err)
+	}
+	general := cfg.Channel("general")
+	if len(general.Feeds) != 2 || general.Feeds[0].Name != "A" || general.Feeds[1].URL != "https://example.com/b.xml" {
+		t.Errorf("Expected config feed to be kept and subscription added, got %+v", general.Feeds)
+	}
+	if general.Feeds[0].FromSlack || !general.Feeds[1].FromSlack {
+		t.Errorf("Expected only the subscribed feed to be marked FromSlack, got %+v", general.Feeds)
+	}
+	if !cfg.HasFeed("news", "https://example.com/c.xml") {
+		t.Errorf("Expected channel from subscriptions, got %+v", cfg.Channels)
+	}
+
+	// LoadConfig leaves the subscriptions out
+	raw, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Channels) != 1 || len(raw.Channels[0].Feeds) != 1 {
+		t.Errorf("Expected only the config file's feeds, got %+v", raw.Channels)
+	}
+}
+
+func TestLoadMergedWithoutChannels(t *testing.T) {
+	path := writeConfig(t, `subscriptions: `+filepath.Join(t.TempDir(), "subscriptions.yaml")+`
+channels: []`)
+	if _, err := LoadMerged(path); err != nil {
+		t.Errorf("Expected a config with only subscriptions to load, got %v", err)
+	}
+}
+
+func TestRemoveSubscription(t *testing.T) {
+	subs := []Subscription{
+		{Channel: "general", URL: "https://example.com/a.xml"},
+		{Channel: "news", URL: "https://example.com/a.xml"},
+	}
+	subs, removed := RemoveSubscription(subs, "news", "https://example.com/a.xml")
+	if !removed || len(subs) != 1 || subs[0].Channel != "general" {
+		t.Errorf("Unexpected result %v %+v", removed, subs)
+	}
+	if _, removed := RemoveSubscription(subs, "news", "https://example.com/a.xml"); removed {
+		t.Error("Expected nothing to remove")
+	}
+}
@@ -0,0 +1,122 @@
+// Package filter decides which feed items are posted using include and
+// exclude rules matched against an item's title, categories and summary.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"slack-rss-feed-manager/rss"
+)
+
+// Rule is a single filter rule. Rules written as /pattern/ are regular
+// expressions; anything else is a keyword matched case-insensitively.
+type Rule struct {
+	raw     string
+	keyword string
+	re      *regexp.Regexp
+}
+
+func ParseRule(s string) (Rule, error) {
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid filter regex %s: %w", s, err)
+		}
+		return Rule{raw: s, re: re}, nil
+	}
+	keyword := strings.ToLower(strings.TrimSpace(s))
+	if keyword == "" {
+		return Rule{}, fmt.Errorf("filter keyword cannot be empty")
+	}
+	return Rule{raw: s, keyword: keyword}, nil
+}
+
+func (r Rule) String() string {
+	return r.raw
+}
+
+// Match reports whether the rule matches any of the item's title, categories
+// or summary. Summaries are compared as plain text.
+func (r Rule) Match(item rss.FeedItem) bool {
+	fields := append([]string{item.Title, rss.PlainText(item.Summary)}, item.Categories...)
+	for _, field := range fields {
+		if r.re != nil && r.re.MatchString(field) {
+			return true
+		}
+		if r.re == nil && strings.Contains(strings.ToLower(field), r.keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set is one level of filtering, such as a channel's or a feed's rules. An
+// item passes if it matches no exclude rule and, when there are include
+// rules, at least one of them.
+type Set struct {
+	Include []Rule
+	Exclude []Rule
+}
+
+func Compile(include, exclude []string) (Set, error) {
+	var set Set
+	for _, s := range include {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return Set{}, err
+		}
+		set.Include = append(set.Include, rule)
+	}
+	for _, s := range exclude {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return Set{}, err
+		}
+		set.Exclude = append(set.Exclude, rule)
+	}
+	return set, nil
+}
+
+// reason returns why the set drops item, or "" if the item passes.
+func (s Set) reason(item rss.FeedItem) string {
+	for _, rule := range s.Exclude {
+		if rule.Match(item) {
+			return "exclude " + rule.String()
+		}
+	}
+	if len(s.Include) == 0 {
+		return ""
+	}
+	names := make([]string, len(s.Include))
+	for i, rule := range s.Include {
+		if rule.Match(item) {
+			return ""
+		}
+		names[i] = rule.String()
+	}
+	return "include " + strings.Join(names, ", ")
+}
+
+// Apply returns the items that pass every set, keeping their order, along
+// with how many items each rule dropped. Items dropped for not matching any
+// include rule are counted against that set's include rules as a group.
+func Apply(items []rss.FeedItem, sets ...Set) ([]rss.FeedItem, map[string]int) {
+	var kept []rss.FeedItem
+	dropped := make(map[string]int)
+	for _, item := range items {
+		reason := ""
+		for _, set := range sets {
+			if reason = set.reason(item); reason != "" {
+				break
+			}
+		}
+		if reason != "" {
+			dropped[reason]++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept, dropped
+}
@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"testing"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        string
+		expectError bool
+	}{
+		{"keyword", "hiring", false},
+		{"regex", `/(?i)v\d+\.\d+/`, false},
+		{"invalid regex", "/[unclosed/", true},
+		{"empty keyword", "  ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	item := rss.FeedItem{
+		Title:      "We're Hiring Engineers",
+		Summary:    "<p>Join us for a <b>webinar</b> on Go 1.23</p>",
+		Categories: []string{"Careers"},
+	}
+
+	tests := []struct {
+		rule     string
+		expected bool
+	}{
+		{"hiring", true},
+		{"WEBINAR", true},
+		{"careers", true},
+		{"kubernetes", false},
+		{`/Go 1\.\d+/`, true},
+		{`/^Hiring/`, false},
+		{"<b>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.Match(item); got != tt.expected {
+				t.Errorf("Match() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	items := []rss.FeedItem{
+		{Title: "Release v1.2"},
+		{Title: "We're hiring"},
+		{Title: "Webinar: release process"},
+		{Title: "Office party"},
+		{Title: "Release v1.3"},
+	}
+
+	channel, err := Compile(nil, []string{"hiring", "webinar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := Compile([]string{"release"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kept, dropped := Apply(items, channel, feed)
+
+	if len(kept) != 2 || kept[0].Title != "Release v1.2" || kept[1].Title != "Release v1.3" {
+		t.Errorf("Unexpected kept items %+v", kept)
+	}
+	expected := map[string]int{
+		"exclude hiring":  1,
+		"exclude webinar": 1,
+		"include release": 1,
+	}
+	if len(dropped) != len(expected) {
+		t.Errorf("Expected drop counts %v, got %v", expected, dropped)
+	}
+	for rule, count := range expected {
+		if dropped[rule] != count {
+			t.Errorf("Expected %q to drop %d, got %d", rule, count, dropped[rule])
+		}
+	}
+
+	t.Run("no rules keeps everything", func(t *testing.T) {
+		kept, dropped := Apply(items, Set{})
+		if len(kept) != len(items) || len(dropped) != 0 {
+			t.Errorf("Expected all items kept, got %d kept and %v dropped", len(kept), dropped)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+// Discord rejects embeds whose fields exceed these lengths; the summary is
+// kept as short as in Slack messages.
+const (
+	maxEmbedTitleLength  = 256
+	maxEmbedAuthorLength = 256
+	maxEmbedFooterLength = 2048
+	maxSummaryLength     = 300
+)
+
+// discord posts items to a Discord webhook as a single embed.
+type discord struct {
+	client Doer
+	url    string
+}
+
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string          `json:"title,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Timestamp   string          `json:"timestamp,omitempty"`
+	Author      *discordName    `json:"author,omitempty"`
+	Footer      *discordFooter  `json:"footer,omitempty"`
+	Thumbnail   *discordPicture `json:"thumbnail,omitempty"`
+}
+
+type discordName struct {
+	Name string `json:"name"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordPicture struct {
+	URL string `json:"url"`
+}
+
+func (n *discord) Notify(item rss.FeedItem) error {
+	return postJSON(n.client, n.url, discordPayload{Embeds: []discordEmbed{discordItem(item)}})
+}
+
+// discordItem builds the embed for an item: the title linking to the item, a
+// plain-text excerpt, the author, the feed title as footer and the thumbnail.
+func discordItem(item rss.FeedItem) discordEmbed {
+	embed := discordEmbed{
+		Title:       rss.Truncate(item.Title, maxEmbedTitleLength),
+		URL:         item.Link,
+		Description: rss.Truncate(rss.PlainText(item.Summary), maxSummaryLength),
+	}
+	if !item.Published.IsZero() {
+		embed.Timestamp = item.Published.UTC().Format(time.RFC3339)
+	}
+	if item.Author != "" {
+		embed.Author = &discordName{Name: rss.Truncate(item.Author, maxEmbedAuthorLength)}
+	}
+	if item.FeedTitle != "" {
+		embed.Footer = &discordFooter{Text: rss.Truncate(item.FeedTitle, maxEmbedFooterLength)}
+	}
+	if item.ImageURL != "" {
+		embed.Thumbnail = &discordPicture{URL: item.ImageURL}
+	}
+	return embed
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestDiscord(t *testing.T) {
+	var body discordPayload
+	server := recordingServer(t, http.StatusNoContent, &body)
+	n, _ := New(Target{Type: Discord, URL: server.URL}, nil, server.Client())
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Embeds) != 1 {
+		t.Fatalf("Expected one embed, got %d", len(body.Embeds))
+	}
+	embed := body.Embeds[0]
+	if embed.Title != "Tips & Tricks" || embed.URL != testItem.Link {
+		t.Errorf("Unexpected title %q or URL %q", embed.Title, embed.URL)
+	}
+	if embed.Description != "First paragraph with bold text." {
+		t.Errorf("Expected plain text description, got %q", embed.Description)
+	}
+	if embed.Timestamp != "2025-07-25T15:00:00Z" {
+		t.Errorf("Unexpected timestamp %q", embed.Timestamp)
+	}
+	if embed.Author == nil || embed.Author.Name != "Jane Doe" {
+		t.Errorf("Unexpected author %v", embed.Author)
+	}
+	if embed.Footer == nil || embed.Footer.Text != "Example Blog" {
+		t.Errorf("Unexpected footer %v", embed.Footer)
+	}
+	if embed.Thumbnail == nil || embed.Thumbnail.URL != testItem.ImageURL {
+		t.Errorf("Unexpected thumbnail %v", embed.Thumbnail)
+	}
+}
+
+func TestDiscordItemMinimal(t *testing.T) {
+	embed := discordItem(rss.FeedItem{Title: strings.Repeat("a", 300)})
+
+	if len([]rune(embed.Title)) != maxEmbedTitleLength {
+		t.Errorf("Expected title truncated to %d runes, got %d", maxEmbedTitleLength, len([]rune(embed.Title)))
+	}
+	if embed.Timestamp != "" || embed.Author != nil || embed.Footer != nil || embed.Thumbnail != nil {
+		t.Errorf("Expected empty optional fields, got %#v", embed)
+	}
+}
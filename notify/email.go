@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+// email sends items as multipart email with a plain-text and an HTML version,
+// either one message per item or several items in one message.
+type email struct {
+	mailer   Mailer
+	from     *mail.Address
+	to       []*mail.Address
+	maxItems int
+}
+
+func newEmail(target Target) (*email, error) {
+	if target.Mailer == nil {
+		return nil, errors.New("email target needs a mailer")
+	}
+	from, err := mail.ParseAddress(target.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", target.From, err)
+	}
+	if len(target.To) == 0 {
+		return nil, errors.New("email target needs recipients")
+	}
+	to := make([]*mail.Address, len(target.To))
+	for i, addr := range target.To {
+		if to[i], err = mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", addr, err)
+		}
+	}
+	return &email{mailer: target.Mailer, from: from, to: to, maxItems: target.MaxItems}, nil
+}
+
+func (n *email) Notify(item rss.FeedItem) error {
+	return n.NotifyAll([]rss.FeedItem{item})
+}
+
+func (n *email) NotifyAll(items []rss.FeedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	shown, more := newest(items, n.maxItems)
+	msg, err := emailMessage(n.from, n.to, shown, more, time.Now())
+	if err != nil {
+		return err
+	}
+	rcpt := make([]string, len(n.to))
+	for i, addr := range n.to {
+		rcpt[i] = addr.Address
+	}
+	return n.mailer.SendMail(n.from.Address, rcpt, msg)
+}
+
+// emailMessage builds the message for items, with headers. more counts the
+// items that were left out.
+func emailMessage(from *mail.Address, to []*mail.Address, items []rss.FeedItem, more int, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	if err := writePart(parts, "text/plain; charset=utf-8", emailText(items, more)); err != nil {
+		return nil, err
+	}
+	html, err := emailHTML(items, more)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePart(parts, "text/html; charset=utf-8", html); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	// Encoding also keeps line breaks in feed titles out of the header
+	header("Subject", mime.QEncoding.Encode("utf-8", emailSubject(items, more)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType, content string) error {
+	w, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// emailSubject is the item's title for a single item, and a count otherwise.
+func emailSubject(items []rss.FeedItem, more int) string {
+	if len(items) == 1 && more == 0 {
+		if items[0].FeedTitle == "" {
+			return items[0].Title
+		}
+		return items[0].FeedTitle + ": " + items[0].Title
+	}
+	feed := items[0].FeedTitle
+	for _, item := range items[1:] {
+		if item.FeedTitle != feed {
+			feed = ""
+			break
+		}
+	}
+	if feed == "" {
+		return fmt.Sprintf("%d new items", len(items)+more)
+	}
+	return fmt.Sprintf("%d new items from %s", len(items)+more, feed)
+}
+
+// emailText renders the plain-text version of items.
+func emailText(items []rss.FeedItem, more int) string {
+	var b strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		for _, line := range []string{item.FeedTitle, item.Title, item.Link, byline(item), emailSummary(item)} {
+			if line != "" {
+				b.WriteString(line + "\n")
+			}
+		}
+	}
+	if more > 0 {
+		fmt.Fprintf(&b, "\nand %d more\n", more)
+	}
+	return b.String()
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+{{- range .Items }}
+<div style="margin-bottom: 24px">
+{{- with .FeedTitle }}
+<div style="color: #666; font-size: 12px">{{ . }}</div>
+{{- end }}
+<h2 style="margin: 4px 0; font-size: 18px">{{ if .Link }}<a href="{{ .Link }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}</h2>
+{{- with .Byline }}
+<div style="color: #666; font-size: 12px">{{ . }}</div>
+{{- end }}
+{{- with .Summary }}
+<p>{{ . }}</p>
+{{- end }}
+</div>
+{{- end }}
+{{- if .More }}
+<p style="color: #666">and {{ .More }} more</p>
+{{- end }}
+</body></html>
+`))
+
+// emailHTML renders the HTML version of items. Summaries are included as
+// plain text, so no markup from feeds ends up in the message.
+func emailHTML(items []rss.FeedItem, more int) (string, error) {
+	type entry struct {
+		FeedTitle, Title, Link, Byline, Summary string
+	}
+	entries := make([]entry, len(items))
+	for i, item := range items {
+		entries[i] = entry{item.FeedTitle, item.Title, item.Link, byline(item), emailSummary(item)}
+	}
+	var b strings.Builder
+	data := struct {
+		Items []entry
+		More  int
+	}{entries, more}
+	if err := emailTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func emailSummary(item rss.FeedItem) string {
+	return rss.Truncate(rss.PlainText(item.Summary), maxSummaryLength)
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"slack-rss-feed-manager/rss"
+)
+
+type fakeMailer struct {
+	from string
+	to   []string
+	msgs [][]byte
+	err  error
+}
+
+func (m *fakeMailer) SendMail(from string, to []string, msg []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.from, m.to = from, to
+	m.msgs = append(m.msgs, msg)
+	return nil
+}
+
+// parseEmail returns the subject and the plain-text and HTML parts of msg.
+func parseEmail(t *testing.T, msg []byte) (subject, text, html string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(string(msg)))
+	if err != nil {
+		t.Fatalf("Invalid message: %v\n%s", err, msg)
+	}
+	subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q", m.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The reader decodes quoted-printable parts
+		data, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch part.Header.Get("Content-Type") {
+		case "text/plain; charset=utf-8":
+			text = string(data)
+		case "text/html; charset=utf-8":
+			html = string(data)
+		}
+	}
+	return subject, text, html
+}
+
+func newTestEmail(t *testing.T, mailer Mailer) Notifier {
+	t.Helper()
+	n, err := New(Target{
+		Type:   Email,
+		Mailer: mailer,
+		From:   "RSS <rss@example.com>",
+		To:     []string{"team@example.com", "Jane <jane@example.com>"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestEmail(t *testing.T) {
+	mailer := &fakeMailer{}
+	n := newTestEmail(t, mailer)
+
+	item := testItem
+	item.Summary = `<p>Read <script>alert(1)</script>"this" &amp; that.</p>`
+	if err := n.Notify(item); err != nil {
+		t.Fatal(err)
+	}
+	if mailer.from != "rss@example.com" || strings.Join(mailer.to, ",") != "team@example.com,jane@example.com" {
+		t.Errorf("Unexpected envelope %q -> %q", mailer.from, mailer.to)
+	}
+	if len(mailer.msgs) != 1 {
+		t.Fatalf("Expected one message, got %d", len(mailer.msgs))
+	}
+
+	subject, text, html := parseEmail(t, mailer.msgs[0])
+	if subject != "Example Blog: Tips & Tricks" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	wantText := "Example Blog\nTips & Tricks\nhttp://example.com/post\nBy Jane Doe · Jul 25, 2025\nRead \"this\" & that.\n"
+	if strings.ReplaceAll(text, "\r\n", "\n") != wantText {
+		t.Errorf("Unexpected text part %q", text)
+	}
+	for _, want := range []string{
+		`<a href="http://example.com/post">Tips &amp; Tricks</a>`,
+		"By Jane Doe · Jul 25, 2025",
+		"Read &#34;this&#34; &amp; that.",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in HTML part:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("Feed markup leaked into HTML part:\n%s", html)
+	}
+}
+
+func TestEmailGrouped(t *testing.T) {
+	mailer := &fakeMailer{}
+	n := newTestEmail(t, mailer).(BatchNotifier)
+
+	second := rss.FeedItem{Title: "Second", Link: "http://example.com/second", FeedTitle: "Example Blog"}
+	if err := n.NotifyAll([]rss.FeedItem{testItem, second}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.msgs) != 1 {
+		t.Fatalf("Expected one message for both items, got %d", len(mailer.msgs))
+	}
+	subject, text, html := parseEmail(t, mailer.msgs[0])
+	if subject != "2 new items from Example Blog" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	if !strings.Contains(text, "Tips & Tricks") || !strings.Contains(text, "Second") {
+		t.Errorf("Expected both items in text part %q", text)
+	}
+	if strings.Count(html, "<h2") != 2 {
+		t.Errorf("Expected both items in HTML part:\n%s", html)
+	}
+
+	if err := n.NotifyAll(nil); err != nil || len(mailer.msgs) != 1 {
+		t.Errorf("Expected nothing sent for no items, got %v", err)
+	}
+
+	capped, _ := New(Target{Type: Email, Mailer: mailer, From: "rss@example.com", To: []string{"team@example.com"}, MaxItems: 1}, nil, nil)
+	if err := capped.(BatchNotifier).NotifyAll([]rss.FeedItem{testItem, second}); err != nil {
+		t.Fatal(err)
+	}
+	subject, text, html = parseEmail(t, mailer.msgs[1])
+	if subject != "2 new items from Example Blog" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	if strings.Count(html, "<h2") != 1 || !strings.Contains(html, "and 1 more") || !strings.Contains(text, "and 1 more") {
+		t.Errorf("Expected one item and an overflow note:\n%s\n%s", text, html)
+	}
+}
+
+func TestEmailSubject(t *testing.T) {
+	tests := []struct {
+		items []rss.FeedItem
+		want  string
+	}{
+		{[]rss.FeedItem{{Title: "Hello"}}, "Hello"},
+		{[]rss.FeedItem{{Title: "Hello", FeedTitle: "Blog"}}, "Blog: Hello"},
+		{[]rss.FeedItem{{Title: "A", FeedTitle: "Blog"}, {Title: "B", FeedTitle: "News"}}, "2 new items"},
+	}
+	for _, tt := range tests {
+		if got := emailSubject(tt.items, 0); got != tt.want {
+			t.Errorf("emailSubject() = %q, want %q", got, tt.want)
+		}
+	}
+
+	// Line breaks in titles must not end the header
+	from, _ := mail.ParseAddress("rss@example.com")
+	msg, err := emailMessage(from, []*mail.Address{from}, []rss.FeedItem{{Title: "Hi\r\nBcc: evil@example.com"}}, 0, testItem.Published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mail.ReadMessage(strings.NewReader(string(msg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Header["Bcc"]) != 0 {
+		t.Errorf("Header injection in message:\n%s", msg)
+	}
+}
+
+func TestEmailErrors(t *testing.T) {
+	n := newTestEmail(t, &fakeMailer{err: errors.New("connection refused")})
+	if err := n.Notify(testItem); err == nil || err.Error() != "connection refused" {
+		t.Errorf("Expected the mailer's error, got %v", err)
+	}
+
+	for _, target := range []Target{
+		{Type: Email, From: "rss@example.com", To: []string{"team@example.com"}},
+		{Type: Email, Mailer: &fakeMailer{}, From: "not an address", To: []string{"team@example.com"}},
+		{Type: Email, Mailer: &fakeMailer{}, From: "rss@example.com"},
+	} {
+		if _, err := New(target, nil, nil); err == nil {
+			t.Errorf("Expected error for %+v", target)
+		}
+	}
+}
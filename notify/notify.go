@@ -0,0 +1,199 @@
+// Package notify delivers feed items to the places a channel can point at:
+// Slack through the bot or an incoming webhook, Discord, Microsoft Teams, or
+// any service that accepts a JSON webhook.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"slack-rss-feed-manager/render"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+// Target types.
+const (
+	SlackBot     = "slack"
+	SlackWebhook = "slack_webhook"
+	Discord      = "discord"
+	Teams        = "teams"
+	Webhook      = "webhook"
+	Email        = "email"
+)
+
+// Types lists every target type.
+var Types = []string{SlackBot, SlackWebhook, Discord, Teams, Webhook, Email}
+
+// IsWebhook reports whether the target type posts to a webhook URL.
+func IsWebhook(targetType string) bool {
+	return slices.Contains([]string{SlackWebhook, Discord, Teams, Webhook}, targetType)
+}
+
+// Notifier delivers feed items to one destination, each in its own format.
+type Notifier interface {
+	Notify(item rss.FeedItem) error
+}
+
+// BatchNotifier is implemented by notifiers that can deliver several items in
+// a single message.
+type BatchNotifier interface {
+	Notifier
+	NotifyAll(items []rss.FeedItem) error
+}
+
+// ThreadNotifier is implemented by notifiers that can post items as replies
+// in a thread.
+type ThreadNotifier interface {
+	Notifier
+	// StartThread posts a message announcing items and returns its timestamp.
+	StartThread(items []rss.FeedItem) (string, error)
+	// NotifyThread delivers item as a reply to the message with timestamp
+	// thread, or as a new message if thread is empty, and returns the message
+	// it posted.
+	NotifyThread(item rss.FeedItem, thread string) (Posted, error)
+}
+
+// Editor is implemented by notifiers whose messages can be changed after they
+// are posted.
+type Editor interface {
+	Notifier
+	// Post delivers item like Notify and returns the message it posted.
+	Post(item rss.FeedItem) (Posted, error)
+	// Update replaces a posted message with item's current content.
+	Update(msg Posted, item rss.FeedItem) error
+	// Annotate replies in the thread of a posted message that item changed
+	// since it was posted as previous.
+	Annotate(msg Posted, previous, item rss.FeedItem) error
+}
+
+// Posted identifies a message posted to Slack.
+type Posted struct {
+	// Channel is the ID of the channel, which Slack needs to update the message.
+	Channel string
+	TS      string
+	// Thread is the timestamp of the thread the message replies in, if any.
+	Thread string
+}
+
+// Doer sends HTTP requests. *http.Client implements it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Target describes where items go.
+type Target struct {
+	// Type is one of Types; empty means SlackBot.
+	Type string
+	// Channel is the Slack channel the bot posts to.
+	Channel string
+	// URL is the webhook URL for every type but SlackBot.
+	URL string
+	// Template, if set, renders the message text of the Slack types.
+	Template *render.Template
+	// Mailer sends the messages of email targets, from From to To.
+	Mailer Mailer
+	From   string
+	To     []string
+	// MaxItems caps the items listed in a message with several items; the
+	// rest are only counted. Zero lists all items, as far as the target allows.
+	MaxItems int
+}
+
+// New returns the notifier for target. Slack bot messages are sent through
+// poster, and webhooks through client.
+func New(target Target, poster slack.Poster, client Doer) (Notifier, error) {
+	switch target.Type {
+	case "", SlackBot:
+		return &slackBot{poster: poster, channel: target.Channel, template: target.Template, maxItems: target.MaxItems}, nil
+	case SlackWebhook:
+		return &slackWebhook{client: client, url: target.URL, template: target.Template, maxItems: target.MaxItems}, nil
+	case Discord:
+		return &discord{client: client, url: target.URL}, nil
+	case Teams:
+		return &teams{client: client, url: target.URL}, nil
+	case Webhook:
+		return &webhook{client: client, url: target.URL}, nil
+	case Email:
+		return newEmail(target)
+	default:
+		return nil, fmt.Errorf("unknown target type %q", target.Type)
+	}
+}
+
+// StatusError is returned when a webhook answers with an unsuccessful status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook returned HTTP %d", e.Code)
+	}
+	return fmt.Sprintf("webhook returned HTTP %d: %s", e.Code, e.Body)
+}
+
+// postJSON sends payload to url as JSON.
+func postJSON(client Doer, url string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Code: resp.StatusCode, Body: string(bytes.TrimSpace(body))}
+	}
+	return nil
+}
+
+// byline returns the author and date line shown under an item's title, or ""
+// when the item has neither.
+func byline(item rss.FeedItem) string {
+	var parts []string
+	if item.Author != "" {
+		parts = append(parts, "By "+item.Author)
+	}
+	if !item.Published.IsZero() {
+		parts = append(parts, item.Published.Format("Jan 2, 2006"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// newest returns the limit newest items in their original order, and how
+// many were left out. A limit of zero keeps every item.
+func newest(items []rss.FeedItem, limit int) ([]rss.FeedItem, int) {
+	if limit <= 0 || len(items) <= limit {
+		return items, 0
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return items[order[a]].Published.After(items[order[b]].Published)
+	})
+	keep := order[:limit]
+	sort.Ints(keep)
+	kept := make([]rss.FeedItem, len(keep))
+	for i, j := range keep {
+		kept[i] = items[j]
+	}
+	return kept, len(items) - limit
+}
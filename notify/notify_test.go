@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+var testItem = rss.FeedItem{
+	ID:         "post-1",
+	Title:      "Tips & Tricks",
+	Link:       "http://example.com/post",
+	Published:  time.Date(2025, 7, 25, 15, 0, 0, 0, time.UTC),
+	FeedTitle:  "Example Blog",
+	Author:     "Jane Doe",
+	Summary:    "<p>First paragraph with <b>bold</b> text.</p>",
+	ImageURL:   "http://example.com/thumb.png",
+	Categories: []string{"go"},
+}
+
+// recordingServer starts a server that answers every request with status and
+// stores the decoded JSON body of the last one in body.
+func recordingServer(t *testing.T, status int, body any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", ct)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, body); err != nil {
+			t.Errorf("Invalid JSON body %s: %v", data, err)
+		}
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte("invalid_payload\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakePoster records the latest message and posts everything to channel C123.
+type fakePoster struct {
+	channel string
+	msg     slack.Message
+	posts   int
+	// updated is the timestamp of the latest updated message.
+	updated string
+}
+
+func (p *fakePoster) PostMessage(channel string, msg slack.Message) (string, string, error) {
+	p.channel, p.msg = channel, msg
+	p.posts++
+	return "C123", fmt.Sprintf("%d.000100", p.posts), nil
+}
+
+func (p *fakePoster) UpdateMessage(channelID, ts string, msg slack.Message) error {
+	p.channel, p.msg, p.updated = channelID, msg, ts
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	for _, targetType := range append([]string{""}, Types...) {
+		target := Target{Type: targetType, Channel: "news", URL: "http://example.com", Mailer: &fakeMailer{}, From: "rss@example.com", To: []string{"team@example.com"}}
+		if _, err := New(target, &fakePoster{}, http.DefaultClient); err != nil {
+			t.Errorf("New(%q) failed: %v", targetType, err)
+		}
+	}
+	if _, err := New(Target{Type: "pager"}, &fakePoster{}, http.DefaultClient); err == nil {
+		t.Error("Expected error for unknown target type")
+	}
+}
+
+func TestIsWebhook(t *testing.T) {
+	tests := map[string]bool{
+		"":           false,
+		SlackBot:     false,
+		SlackWebhook: true,
+		Discord:      true,
+		Teams:        true,
+		Webhook:      true,
+		Email:        false,
+		"pager":      false,
+	}
+	for targetType, want := range tests {
+		if got := IsWebhook(targetType); got != want {
+			t.Errorf("IsWebhook(%q) = %v, want %v", targetType, got, want)
+		}
+	}
+}
+
+func TestPostJSONStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway} {
+		var body map[string]any
+		server := recordingServer(t, status, &body)
+
+		err := postJSON(server.Client(), server.URL, map[string]string{"text": "hi"})
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("Expected StatusError for HTTP %d, got %v", status, err)
+		}
+		if statusErr.Code != status || statusErr.Body != "invalid_payload" {
+			t.Errorf("Unexpected error %v", statusErr)
+		}
+	}
+}
+
+func TestPostJSONAcceptsNoContent(t *testing.T) {
+	var body map[string]any
+	server := recordingServer(t, http.StatusNoContent, &body)
+	if err := postJSON(server.Client(), server.URL, map[string]string{"text": "hi"}); err != nil {
+		t.Errorf("Expected 204 to succeed, got %v", err)
+	}
+}
+
+func TestNewest(t *testing.T) {
+	base := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	items := []rss.FeedItem{
+		{ID: "a", Published: base.Add(3 * time.Hour)},
+		{ID: "b", Published: base},
+		{ID: "c", Published: base.Add(time.Hour)},
+		{ID: "d", Published: base.Add(2 * time.Hour)},
+	}
+
+	kept, more := newest(items, 2)
+	if more != 2 || len(kept) != 2 || kept[0].ID != "a" || kept[1].ID != "d" {
+		t.Errorf("Expected a and d in their original order with 2 more, got %v and %d", kept, more)
+	}
+	if kept, more := newest(items, 0); len(kept) != 4 || more != 0 {
+		t.Errorf("Expected every item without a limit, got %d and %d more", len(kept), more)
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	goslack "github.com/slack-go/slack"
+
+	"slack-rss-feed-manager/render"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+// slackBot posts items through the Slack bot.
+type slackBot struct {
+	poster   slack.Poster
+	channel  string
+	template *render.Template
+	maxItems int
+}
+
+func (n *slackBot) Notify(item rss.FeedItem) error {
+	_, err := n.Post(item)
+	return err
+}
+
+func (n *slackBot) Post(item rss.FeedItem) (Posted, error) {
+	return n.NotifyThread(item, "")
+}
+
+func (n *slackBot) NotifyAll(items []rss.FeedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	_, _, err := n.poster.PostMessage("#"+n.channel, slackDigest(items, n.maxItems))
+	return err
+}
+
+func (n *slackBot) StartThread(items []rss.FeedItem) (string, error) {
+	text := fmt.Sprintf("%d new items", len(items))
+	if len(items) > 0 && items[0].FeedTitle != "" {
+		text += " from " + slack.Escape(items[0].FeedTitle)
+	}
+	_, ts, err := n.poster.PostMessage("#"+n.channel, slack.Message{Text: text})
+	return ts, err
+}
+
+func (n *slackBot) NotifyThread(item rss.FeedItem, thread string) (Posted, error) {
+	msg := SlackMessage(item, n.template)
+	msg.ThreadTS = thread
+	channelID, ts, err := n.poster.PostMessage("#"+n.channel, msg)
+	return Posted{Channel: channelID, TS: ts, Thread: thread}, err
+}
+
+func (n *slackBot) Update(msg Posted, item rss.FeedItem) error {
+	return n.poster.UpdateMessage(msg.Channel, msg.TS, SlackMessage(item, n.template))
+}
+
+func (n *slackBot) Annotate(msg Posted, previous, item rss.FeedItem) error {
+	note := slack.EditMessage(previous, item)
+	// Replies to a reply go to the thread it is in
+	note.ThreadTS = msg.TS
+	if msg.Thread != "" {
+		note.ThreadTS = msg.Thread
+	}
+	_, _, err := n.poster.PostMessage("#"+n.channel, note)
+	return err
+}
+
+// slackWebhook posts items to a Slack incoming webhook.
+type slackWebhook struct {
+	client   Doer
+	url      string
+	template *render.Template
+	maxItems int
+}
+
+// slackPayload is the body Slack incoming webhooks accept.
+type slackPayload struct {
+	Text   string          `json:"text"`
+	Blocks []goslack.Block `json:"blocks,omitempty"`
+}
+
+func (n *slackWebhook) Notify(item rss.FeedItem) error {
+	msg := SlackMessage(item, n.template)
+	return postJSON(n.client, n.url, slackPayload{Text: msg.Text, Blocks: msg.Blocks})
+}
+
+func (n *slackWebhook) NotifyAll(items []rss.FeedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	msg := slackDigest(items, n.maxItems)
+	return postJSON(n.client, n.url, slackPayload{Text: msg.Text, Blocks: msg.Blocks})
+}
+
+// slackDigest lists items in one message, at most limit of them and never more
+// than a Slack message can hold.
+func slackDigest(items []rss.FeedItem, limit int) slack.Message {
+	if limit <= 0 || limit > slack.MaxDigestItems {
+		limit = slack.MaxDigestItems
+	}
+	shown, more := newest(items, limit)
+	return slack.DigestMessage(shown, more)
+}
+
+// SlackMessage renders an item with tmpl, or as the default Block Kit message
+// when there is none or it fails for this item.
+func SlackMessage(item rss.FeedItem, tmpl *render.Template) slack.Message {
+	if tmpl == nil {
+		return slack.ItemMessage(item)
+	}
+	text, err := tmpl.Execute(item)
+	if err != nil {
+		log.Printf("Template failed for %s, using default format: %v", item.Link, err)
+		return slack.ItemMessage(item)
+	}
+	return slack.Message{Text: text}
+}
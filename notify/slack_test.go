@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/render"
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+func TestSlackBot(t *testing.T) {
+	poster := &fakePoster{}
+	n, _ := New(Target{Channel: "news"}, poster, nil)
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if poster.channel != "#news" {
+		t.Errorf("Expected post to #news, got %q", poster.channel)
+	}
+	if poster.msg.Text != rss.FormatItem(testItem) || len(poster.msg.Blocks) == 0 {
+		t.Errorf("Expected the default Block Kit message, got %#v", poster.msg)
+	}
+}
+
+func TestSlackWebhook(t *testing.T) {
+	var body struct {
+		Text   string           `json:"text"`
+		Blocks []map[string]any `json:"blocks"`
+	}
+	server := recordingServer(t, http.StatusOK, &body)
+	n, _ := New(Target{Type: SlackWebhook, URL: server.URL}, nil, server.Client())
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if body.Text != rss.FormatItem(testItem) {
+		t.Errorf("Unexpected text %q", body.Text)
+	}
+	if len(body.Blocks) != 4 || body.Blocks[0]["type"] != "header" {
+		t.Errorf("Expected the default blocks, got %v", body.Blocks)
+	}
+}
+
+func TestSlackWebhookTemplate(t *testing.T) {
+	var body map[string]any
+	server := recordingServer(t, http.StatusOK, &body)
+	tmpl, err := render.Parse("test", "{{ escape .Title }} by {{ .Author }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := New(Target{Type: SlackWebhook, URL: server.URL, Template: tmpl}, nil, server.Client())
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if body["text"] != "Tips &amp; Tricks by Jane Doe" {
+		t.Errorf("Unexpected text %q", body["text"])
+	}
+	if _, ok := body["blocks"]; ok {
+		t.Errorf("Expected no blocks for a templated message, got %v", body["blocks"])
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	if msg := SlackMessage(testItem, nil); msg.Text != slack.ItemMessage(testItem).Text {
+		t.Errorf("Expected the default message without a template, got %q", msg.Text)
+	}
+
+	tmpl, err := render.Parse("test", "{{ if false }}x{{ end }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := SlackMessage(testItem, tmpl); len(msg.Blocks) == 0 {
+		t.Error("Expected the default message when the template fails")
+	}
+}
+
+func TestSlackDigest(t *testing.T) {
+	poster := &fakePoster{}
+	n, _ := New(Target{Channel: "news", MaxItems: 1}, poster, nil)
+
+	second := rss.FeedItem{Title: "Newer", Link: "http://example.com/newer", FeedTitle: "Example Blog", Published: testItem.Published.Add(time.Hour)}
+	if err := n.(BatchNotifier).NotifyAll([]rss.FeedItem{testItem, second}); err != nil {
+		t.Fatal(err)
+	}
+	if poster.channel != "#news" || poster.msg.Text != "2 new items" {
+		t.Errorf("Unexpected digest %q to %q", poster.msg.Text, poster.channel)
+	}
+	data, err := json.Marshal(poster.msg.Blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Newer") || strings.Contains(string(data), "Tips") || !strings.Contains(string(data), "and 1 more") {
+		t.Errorf("Expected only the newest item and an overflow note, got %s", data)
+	}
+
+	var body map[string]any
+	server := recordingServer(t, http.StatusOK, &body)
+	n, _ = New(Target{Type: SlackWebhook, URL: server.URL}, nil, server.Client())
+	if err := n.(BatchNotifier).NotifyAll([]rss.FeedItem{testItem, second}); err != nil {
+		t.Fatal(err)
+	}
+	if body["text"] != "2 new items" {
+		t.Errorf("Unexpected webhook digest %q", body["text"])
+	}
+}
+
+func TestSlackThread(t *testing.T) {
+	poster := &fakePoster{}
+	n, _ := New(Target{Channel: "news"}, poster, nil)
+	threader := n.(ThreadNotifier)
+
+	ts, err := threader.StartThread([]rss.FeedItem{testItem, testItem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "1.000100" || poster.msg.Text != "2 new items from Example Blog" || poster.msg.ThreadTS != "" {
+		t.Errorf("Unexpected thread start %q: %+v", ts, poster.msg)
+	}
+
+	posted, err := threader.NotifyThread(testItem, "1.000100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted != (Posted{Channel: "C123", TS: "2.000100", Thread: "1.000100"}) || poster.msg.ThreadTS != "1.000100" || len(poster.msg.Blocks) == 0 {
+		t.Errorf("Expected the item as a reply, got %+v: %+v", posted, poster.msg)
+	}
+
+	if err := n.Notify(testItem); err != nil || poster.msg.ThreadTS != "" {
+		t.Errorf("Expected Notify to post a new message, got %+v (%v)", poster.msg, err)
+	}
+}
+
+func TestSlackEdits(t *testing.T) {
+	poster := &fakePoster{}
+	n, _ := New(Target{Channel: "news"}, poster, nil)
+	editor := n.(Editor)
+
+	posted, err := editor.Post(testItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted != (Posted{Channel: "C123", TS: "1.000100"}) {
+		t.Errorf("Unexpected message %+v", posted)
+	}
+
+	edited := testItem
+	edited.Title = "Renamed"
+	if err := editor.Update(posted, edited); err != nil {
+		t.Fatal(err)
+	}
+	if poster.channel != "C123" || poster.updated != "1.000100" || poster.msg.Text != rss.FormatItem(edited) {
+		t.Errorf("Expected the message to be updated in place, got %s %s: %+v", poster.channel, poster.updated, poster.msg)
+	}
+
+	// Notes about replies go to the thread the reply is in
+	for _, msg := range []Posted{posted, {Channel: "C123", TS: "2.000100", Thread: "1.000100"}} {
+		if err := editor.Annotate(msg, testItem, edited); err != nil {
+			t.Fatal(err)
+		}
+		if poster.channel != "#news" || poster.msg.ThreadTS != "1.000100" || !strings.Contains(poster.msg.Text, "Renamed") {
+			t.Errorf("Expected a note in the thread, got %s: %+v", poster.channel, poster.msg)
+		}
+	}
+}
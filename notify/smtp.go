@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// DefaultSMTPPort is the mail submission port, which uses STARTTLS.
+const DefaultSMTPPort = 587
+
+// smtpTimeout bounds a whole SMTP conversation.
+const smtpTimeout = 30 * time.Second
+
+// Mailer sends email messages.
+type Mailer interface {
+	SendMail(from string, to []string, msg []byte) error
+}
+
+// SMTP sends mail through an SMTP server. The connection is upgraded with
+// STARTTLS whenever the server offers it, and credentials are only sent over
+// TLS (or to localhost).
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLSConfig is used for STARTTLS; nil verifies the server against the
+	// system roots.
+	TLSConfig *tls.Config
+}
+
+func (s *SMTP) SendMail(from string, to []string, msg []byte) error {
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+	port := s.Port
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(s.Host, strconv.Itoa(port)), smtpTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		config := &tls.Config{ServerName: s.Host}
+		if s.TLSConfig != nil {
+			config = s.TLSConfig.Clone()
+			if config.ServerName == "" {
+				config.ServerName = s.Host
+			}
+		}
+		if err := c.StartTLS(config); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server does not support authentication")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
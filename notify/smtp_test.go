@@ -0,0 +1,228 @@
+package notify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is an in-process SMTP stand-in that speaks just enough of the
+// protocol for SendMail: EHLO, STARTTLS, AUTH PLAIN, MAIL, RCPT and DATA.
+type smtpServer struct {
+	addr     string
+	tls      *tls.Config
+	starttls bool
+
+	mu       sync.Mutex
+	upgraded bool
+	auth     string
+	from     string
+	to       []string
+	data     string
+}
+
+// newSMTPServer starts a stand-in on localhost. With starttls it offers
+// STARTTLS using a certificate for 127.0.0.1, and returns a client config
+// trusting it.
+func newSMTPServer(t *testing.T, starttls bool) (*smtpServer, *tls.Config) {
+	t.Helper()
+	cert, pool := testCertificate(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpServer{
+		addr:     ln.Addr().String(),
+		tls:      &tls.Config{Certificates: []tls.Certificate{cert}},
+		starttls: starttls,
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, &tls.Config{RootCAs: pool}
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	upgraded := false
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if s.starttls && !upgraded {
+				tp.PrintfLine("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				tp.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			tp = textproto.NewConn(tlsConn)
+			upgraded = true
+		case "AUTH":
+			s.mu.Lock()
+			s.auth, s.upgraded = arg, upgraded
+			s.mu.Unlock()
+			tp.PrintfLine("235 Authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.to = append(s.to, arg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = strings.Join(lines, "\n")
+			s.mu.Unlock()
+			tp.PrintfLine("250 Queued")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) port(t *testing.T) int {
+	t.Helper()
+	_, port, _ := net.SplitHostPort(s.addr)
+	p, err := net.LookupPort("tcp", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestSMTPStartTLS(t *testing.T) {
+	server, clientTLS := newSMTPServer(t, true)
+	mailer := &SMTP{
+		Host:      "127.0.0.1",
+		Port:      server.port(t),
+		Username:  "rss",
+		Password:  "hunter2",
+		TLSConfig: clientTLS,
+	}
+	n := newTestEmail(t, mailer)
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.upgraded {
+		t.Error("Expected credentials to be sent after STARTTLS")
+	}
+	auth, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(server.auth, "PLAIN "))
+	if string(auth) != "\x00rss\x00hunter2" {
+		t.Errorf("Unexpected credentials %q", auth)
+	}
+	if server.from != "FROM:<rss@example.com>" {
+		t.Errorf("Unexpected sender %q", server.from)
+	}
+	if strings.Join(server.to, ",") != "TO:<team@example.com>,TO:<jane@example.com>" {
+		t.Errorf("Unexpected recipients %q", server.to)
+	}
+	subject, text, _ := parseEmail(t, []byte(server.data))
+	if subject != "Example Blog: Tips & Tricks" || !strings.Contains(text, testItem.Link) {
+		t.Errorf("Unexpected message %q:\n%s", subject, text)
+	}
+}
+
+func TestSMTPUntrustedCertificate(t *testing.T) {
+	server, _ := newSMTPServer(t, true)
+	mailer := &SMTP{Host: "127.0.0.1", Port: server.port(t), Username: "rss", Password: "hunter2"}
+
+	if err := mailer.SendMail("rss@example.com", []string{"team@example.com"}, []byte("Subject: hi\r\n\r\nhi\r\n")); err == nil {
+		t.Error("Expected STARTTLS to fail against an untrusted certificate")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.auth != "" {
+		t.Error("Credentials were sent despite the failed handshake")
+	}
+}
+
+func TestSMTPWithoutTLS(t *testing.T) {
+	server, _ := newSMTPServer(t, false)
+
+	// Without credentials plain SMTP works, e.g. for a local relay
+	mailer := &SMTP{Host: "127.0.0.1", Port: server.port(t)}
+	if err := mailer.SendMail("rss@example.com", []string{"team@example.com"}, []byte("Subject: hi\r\n\r\nhi\r\n")); err != nil {
+		t.Fatalf("SendMail() error = %v", err)
+	}
+	server.mu.Lock()
+	data := server.data
+	server.mu.Unlock()
+	if !strings.Contains(data, "Subject: hi") {
+		t.Errorf("Unexpected data %q", data)
+	}
+
+	if err := mailer.SendMail("rss@example.com", nil, nil); err == nil {
+		t.Error("Expected error without recipients")
+	}
+}
@@ -0,0 +1,79 @@
+package notify
+
+import "slack-rss-feed-manager/rss"
+
+// teams posts items to a Microsoft Teams workflow webhook as an Adaptive Card.
+type teams struct {
+	client Doer
+	url    string
+}
+
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	Actions []teamsAction  `json:"actions,omitempty"`
+}
+
+type teamsElement struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Size     string `json:"size,omitempty"`
+	Weight   string `json:"weight,omitempty"`
+	IsSubtle bool   `json:"isSubtle,omitempty"`
+	Wrap     bool   `json:"wrap"`
+}
+
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+func (n *teams) Notify(item rss.FeedItem) error {
+	return postJSON(n.client, n.url, teamsPayload{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     teamsItem(item),
+		}},
+	})
+}
+
+// teamsItem builds the Adaptive Card for an item: the feed title, the item
+// title, an author and date line, a plain-text excerpt and a button opening
+// the item.
+func teamsItem(item rss.FeedItem) teamsCard {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+	}
+	if item.FeedTitle != "" {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: item.FeedTitle, IsSubtle: true, Wrap: true})
+	}
+	card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: item.Title, Size: "Medium", Weight: "Bolder", Wrap: true})
+
+	if meta := byline(item); meta != "" {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: meta, IsSubtle: true, Wrap: true})
+	}
+
+	if summary := rss.Truncate(rss.PlainText(item.Summary), maxSummaryLength); summary != "" {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: summary, Wrap: true})
+	}
+	if item.Link != "" {
+		card.Actions = []teamsAction{{Type: "Action.OpenUrl", Title: "Open", URL: item.Link}}
+	}
+	return card
+}
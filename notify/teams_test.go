@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"net/http"
+	"testing"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestTeams(t *testing.T) {
+	var body teamsPayload
+	server := recordingServer(t, http.StatusAccepted, &body)
+	n, _ := New(Target{Type: Teams, URL: server.URL}, nil, server.Client())
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if body.Type != "message" || len(body.Attachments) != 1 {
+		t.Fatalf("Expected one attachment, got %#v", body)
+	}
+	if ct := body.Attachments[0].ContentType; ct != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+
+	card := body.Attachments[0].Content
+	if card.Type != "AdaptiveCard" {
+		t.Errorf("Unexpected card type %q", card.Type)
+	}
+	var texts []string
+	for _, element := range card.Body {
+		texts = append(texts, element.Text)
+	}
+	want := []string{"Example Blog", "Tips & Tricks", "By Jane Doe · Jul 25, 2025", "First paragraph with bold text."}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected text blocks %q, got %q", want, texts)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Errorf("Text block %d: expected %q, got %q", i, want[i], texts[i])
+		}
+	}
+	if len(card.Actions) != 1 || card.Actions[0].URL != testItem.Link {
+		t.Errorf("Expected an action opening the item, got %#v", card.Actions)
+	}
+}
+
+func TestTeamsItemMinimal(t *testing.T) {
+	card := teamsItem(rss.FeedItem{Title: "Just a title"})
+
+	if len(card.Body) != 1 || card.Body[0].Text != "Just a title" {
+		t.Errorf("Expected only the title, got %#v", card.Body)
+	}
+	if len(card.Actions) != 0 {
+		t.Errorf("Expected no actions without a link, got %#v", card.Actions)
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+// webhook posts items as plain JSON for services that do their own formatting.
+type webhook struct {
+	client Doer
+	url    string
+}
+
+// WebhookItem is the body sent to generic webhooks.
+type WebhookItem struct {
+	ID         string     `json:"id"`
+	FeedTitle  string     `json:"feed_title,omitempty"`
+	Title      string     `json:"title"`
+	Link       string     `json:"link,omitempty"`
+	Published  *time.Time `json:"published,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	Summary    string     `json:"summary,omitempty"`
+	ImageURL   string     `json:"image_url,omitempty"`
+	Categories []string   `json:"categories,omitempty"`
+}
+
+func (n *webhook) Notify(item rss.FeedItem) error {
+	return postJSON(n.client, n.url, webhookItem(item))
+}
+
+// webhookItem converts an item to its webhook body. The summary is sent as
+// plain text so receivers don't have to deal with feed HTML.
+func webhookItem(item rss.FeedItem) WebhookItem {
+	body := WebhookItem{
+		ID:         item.ID,
+		FeedTitle:  item.FeedTitle,
+		Title:      item.Title,
+		Link:       item.Link,
+		Author:     item.Author,
+		Summary:    rss.PlainText(item.Summary),
+		ImageURL:   item.ImageURL,
+		Categories: item.Categories,
+	}
+	if !item.Published.IsZero() {
+		published := item.Published.UTC()
+		body.Published = &published
+	}
+	return body
+}
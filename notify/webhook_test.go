@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestWebhook(t *testing.T) {
+	var body WebhookItem
+	server := recordingServer(t, http.StatusOK, &body)
+	n, _ := New(Target{Type: Webhook, URL: server.URL}, nil, server.Client())
+
+	if err := n.Notify(testItem); err != nil {
+		t.Fatal(err)
+	}
+	if body.ID != "post-1" || body.Title != "Tips & Tricks" || body.Link != testItem.Link {
+		t.Errorf("Unexpected item %#v", body)
+	}
+	if body.FeedTitle != "Example Blog" || body.Author != "Jane Doe" || body.ImageURL != testItem.ImageURL {
+		t.Errorf("Unexpected item %#v", body)
+	}
+	if body.Summary != "First paragraph with bold text." {
+		t.Errorf("Expected plain text summary, got %q", body.Summary)
+	}
+	if body.Published == nil || !body.Published.Equal(testItem.Published) {
+		t.Errorf("Unexpected published time %v", body.Published)
+	}
+	if len(body.Categories) != 1 || body.Categories[0] != "go" {
+		t.Errorf("Unexpected categories %v", body.Categories)
+	}
+}
+
+func TestWebhookItemPublished(t *testing.T) {
+	if body := webhookItem(rss.FeedItem{Title: "Undated"}); body.Published != nil {
+		t.Errorf("Expected no published time, got %v", body.Published)
+	}
+
+	local := time.Date(2025, 7, 25, 17, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	body := webhookItem(rss.FeedItem{Title: "Dated", Published: local})
+	if body.Published.Location() != time.UTC || !body.Published.Equal(local) {
+		t.Errorf("Expected published time in UTC, got %v", body.Published)
+	}
+}
@@ -0,0 +1,143 @@
+// Package opml converts between OPML subscription lists and the config file.
+// Top-level OPML folders map to Slack channels.
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"slack-rss-feed-manager/config"
+)
+
+type Document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Title   string    `xml:"head>title,omitempty"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+func Parse(r io.Reader) (Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Document{}, fmt.Errorf("invalid OPML: %w", err)
+	}
+	return doc, nil
+}
+
+func (d Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Import merges the feeds in doc into cfg. Each top-level folder becomes a
+// channel named after the folder, and feeds in nested folders go to the
+// channel of their top-level folder. Feeds outside any folder go to
+// defaultChannel, which must then be set. A feed's outline text becomes its
+// name unless it is just the URL. Feeds a channel already has are skipped. It
+// returns the number of feeds added.
+func Import(cfg *config.Config, doc Document, defaultChannel string) (int, error) {
+	added := 0
+	for _, outline := range doc.Body {
+		if outline.XMLURL != "" {
+			if defaultChannel == "" {
+				return added, fmt.Errorf("feed %s is not in a folder and no default channel was given", outline.XMLURL)
+			}
+			if cfg.AddFeed(defaultChannel, outline.feed()) {
+				added++
+			}
+			continue
+		}
+		channel := ChannelName(outline.label())
+		if channel == "" {
+			return added, fmt.Errorf("folder %q has no usable name for a channel", outline.label())
+		}
+		for _, feed := range outline.feeds() {
+			if cfg.AddFeed(channel, feed) {
+				added++
+			}
+		}
+	}
+	return added, nil
+}
+
+// Export writes cfg as OPML with one folder per channel.
+func Export(cfg config.Config) Document {
+	doc := Document{Version: "2.0", Title: "Slack RSS feed subscriptions"}
+	for _, ch := range cfg.Channels {
+		folder := Outline{Text: ch.SlackChannel}
+		for _, feed := range ch.Feeds {
+			text := feed.Name
+			if text == "" {
+				text = feed.URL
+			}
+			folder.Outlines = append(folder.Outlines, Outline{Text: text, Type: "rss", XMLURL: feed.URL})
+		}
+		doc.Body = append(doc.Body, folder)
+	}
+	return doc
+}
+
+func (o Outline) label() string {
+	if o.Text != "" {
+		return o.Text
+	}
+	return o.Title
+}
+
+// feed returns the feed of an outline with an xmlUrl, named after the outline
+// unless its label is empty or the URL itself, as Export writes unnamed feeds.
+func (o Outline) feed() config.Feed {
+	feed := config.Feed{URL: o.XMLURL}
+	if label := strings.TrimSpace(o.label()); label != o.XMLURL {
+		feed.Name = label
+	}
+	return feed
+}
+
+func (o Outline) feeds() []config.Feed {
+	var feeds []config.Feed
+	for _, child := range o.Outlines {
+		if child.XMLURL != "" {
+			feeds = append(feeds, child.feed())
+		}
+		feeds = append(feeds, child.feeds()...)
+	}
+	return feeds
+}
+
+// ChannelName turns a folder name into a Slack channel name: lowercase, with
+// runs of anything other than letters, digits, '-' and '_' replaced by '-'.
+func ChannelName(folder string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimPrefix(strings.TrimSpace(folder), "#")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
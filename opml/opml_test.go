@@ -0,0 +1,167 @@
+package opml
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slack-rss-feed-manager/config"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>My feeds</title></head>
+  <body>
+    <outline text="Tech Blogs">
+      <outline text="Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Databases">
+        <outline text="antirez" type="rss" xmlUrl="https://antirez.com/rss"/>
+      </outline>
+    </outline>
+    <outline title="Release Notes!">
+      <outline text="Tailscale" type="rss" xmlUrl="https://tailscale.com/blog/index.xml"/>
+    </outline>
+    <outline text="Loose feed" type="rss" xmlUrl="https://example.com/loose.xml"/>
+  </body>
+</opml>`
+
+func TestImport(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{SlackChannel: "tech-blogs", Feeds: []config.Feed{{URL: "https://go.dev/blog/feed.atom"}}},
+		},
+	}
+	added, err := Import(&cfg, doc, "misc")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if added != 3 {
+		t.Errorf("Expected 3 feeds added, got %d", added)
+	}
+	expected := map[string][]string{
+		"tech-blogs":    {"https://go.dev/blog/feed.atom", "https://antirez.com/rss"},
+		"release-notes": {"https://tailscale.com/blog/index.xml"},
+		"misc":          {"https://example.com/loose.xml"},
+	}
+	if len(cfg.Channels) != len(expected) {
+		t.Fatalf("Expected %d channels, got %d", len(expected), len(cfg.Channels))
+	}
+	for name, urls := range expected {
+		ch := cfg.Channel(name)
+		if ch == nil {
+			t.Errorf("Expected channel %s", name)
+			continue
+		}
+		if len(ch.Feeds) != len(urls) {
+			t.Errorf("Channel %s: expected %d feeds, got %+v", name, len(urls), ch.Feeds)
+			continue
+		}
+		for i, url := range urls {
+			if ch.Feeds[i].URL != url {
+				t.Errorf("Channel %s feed %d: expected %s, got %s", name, i, url, ch.Feeds[i].URL)
+			}
+		}
+	}
+
+	// Outline texts become feed names; feeds already in the config keep theirs
+	for channel, names := range map[string][]string{"tech-blogs": {"", "antirez"}, "misc": {"Loose feed"}} {
+		for i, name := range names {
+			if got := cfg.Channel(channel).Feeds[i].Name; got != name {
+				t.Errorf("Channel %s feed %d: expected name %q, got %q", channel, i, name, got)
+			}
+		}
+	}
+
+	t.Run("importing again adds nothing", func(t *testing.T) {
+		added, err := Import(&cfg, doc, "misc")
+		if err != nil || added != 0 {
+			t.Errorf("Expected no feeds added, got %d (err %v)", added, err)
+		}
+	})
+
+	t.Run("loose feeds need a default channel", func(t *testing.T) {
+		var empty config.Config
+		if _, err := Import(&empty, doc, ""); err == nil {
+			t.Error("Expected error without default channel, got nil")
+		}
+	})
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	cfg := config.Config{
+		Channels: []config.Channel{
+			{
+				SlackChannel: "tech-blog-alerts",
+				Feeds: []config.Feed{
+					{URL: "https://antirez.com/rss"},
+					{URL: "https://go.dev/blog/feed.atom", Name: "Go Blog"},
+				},
+			},
+			{SlackChannel: "releases", Feeds: []config.Feed{{URL: "https://tailscale.com/blog/index.xml"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Export(cfg).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `text="Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"`) {
+		t.Errorf("Expected feed name as outline text, got:\n%s", buf.String())
+	}
+
+	doc, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var imported config.Config
+	if _, err := Import(&imported, doc, ""); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	// Write the imported config and load it back through the YAML loader
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := config.SaveConfig(path, imported); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	loaded, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(loaded.Channels) != len(cfg.Channels) {
+		t.Fatalf("Expected %d channels, got %d", len(cfg.Channels), len(loaded.Channels))
+	}
+	for i, ch := range cfg.Channels {
+		got := loaded.Channels[i]
+		if got.SlackChannel != ch.SlackChannel || len(got.Feeds) != len(ch.Feeds) {
+			t.Errorf("Channel %d: expected %+v, got %+v", i, ch, got)
+			continue
+		}
+		for j, feed := range ch.Feeds {
+			if got.Feeds[j].URL != feed.URL || got.Feeds[j].Name != feed.Name {
+				t.Errorf("Channel %s feed %d: expected %s %q, got %s %q", ch.SlackChannel, j, feed.URL, feed.Name, got.Feeds[j].URL, got.Feeds[j].Name)
+			}
+		}
+	}
+}
+
+func TestChannelName(t *testing.T) {
+	tests := map[string]string{
+		"Tech Blogs":      "tech-blogs",
+		"#releases":       "releases",
+		"  Go / Rust!! ":  "go-rust",
+		"already-fine_ok": "already-fine_ok",
+		"!!!":             "",
+	}
+	for folder, expected := range tests {
+		if got := ChannelName(folder); got != expected {
+			t.Errorf("ChannelName(%q) = %q, want %q", folder, got, expected)
+		}
+	}
+}
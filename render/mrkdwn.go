@@ -0,0 +1,133 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"slack-rss-feed-manager/slack"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// HTMLToMrkdwn converts the common inline and block HTML found in feed
+// summaries to Slack mrkdwn. Tags it doesn't know are dropped, keeping their text.
+func HTMLToMrkdwn(s string) string {
+	var b strings.Builder
+	var links []string
+	skip, pre := 0, 0
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if pre > 0 {
+				b.WriteString(slack.Escape(token.Data))
+			} else {
+				text := collapse(token.Data)
+				if b.Len() == 0 || strings.HasSuffix(b.String(), "\n") {
+					text = strings.TrimLeft(text, " ")
+				}
+				b.WriteString(slack.Escape(text))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.Data {
+			case "script", "style":
+				skip++
+			case "b", "strong":
+				b.WriteString("*")
+			case "i", "em":
+				b.WriteString("_")
+			case "s", "del", "strike":
+				b.WriteString("~")
+			case "code":
+				b.WriteString("`")
+			case "pre":
+				pre++
+				b.WriteString("\n```")
+			case "br":
+				b.WriteString("\n")
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "blockquote":
+				b.WriteString("\n\n")
+			case "li":
+				b.WriteString("\n• ")
+			case "a":
+				href := attr(token, "href")
+				links = append(links, href)
+				if href != "" {
+					b.WriteString("<" + href + "|")
+				}
+			}
+		case html.EndTagToken:
+			switch token.Data {
+			case "script", "style":
+				if skip > 0 {
+					skip--
+				}
+			case "b", "strong":
+				b.WriteString("*")
+			case "i", "em":
+				b.WriteString("_")
+			case "s", "del", "strike":
+				b.WriteString("~")
+			case "code":
+				b.WriteString("`")
+			case "pre":
+				if pre > 0 {
+					pre--
+				}
+				b.WriteString("```\n")
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "blockquote":
+				b.WriteString("\n\n")
+			case "a":
+				if n := len(links); n > 0 {
+					if links[n-1] != "" {
+						b.WriteString(">")
+					}
+					links = links[:n-1]
+				}
+			}
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func collapse(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(fields, " ")
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		out = " " + out
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		out += " "
+	}
+	return out
+}
+
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
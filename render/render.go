@@ -0,0 +1,74 @@
+// Package render formats feed items with user-supplied text/template templates.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+	"slack-rss-feed-manager/slack"
+)
+
+// Template renders a feed item. The item's fields are available as the dot,
+// e.g. {{ .Title }}, alongside the helpers in Funcs.
+type Template struct {
+	tmpl *template.Template
+}
+
+// Funcs are the helper functions available to templates:
+//
+//	truncate N S   shorten S to N characters at a word boundary
+//	date LAYOUT T  format T with a Go time layout, empty for undated items
+//	plain S        strip HTML from S
+//	mrkdwn S       convert HTML in S to Slack mrkdwn
+//	escape S       escape S for use in Slack mrkdwn
+var Funcs = template.FuncMap{
+	"truncate": func(n int, s string) string { return rss.Truncate(s, n) },
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+	"plain":  rss.PlainText,
+	"mrkdwn": HTMLToMrkdwn,
+	"escape": slack.Escape,
+}
+
+// sample is executed when parsing so that references to unknown fields are
+// reported up front rather than when the first item is posted.
+var sample = rss.FeedItem{
+	ID:        "sample",
+	Title:     "Sample title",
+	Link:      "https://example.com/post",
+	Published: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+	FeedTitle: "Sample feed",
+	Author:    "Sample author",
+	Summary:   "<p>Sample summary</p>",
+}
+
+func Parse(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+func (t *Template) Execute(item rss.FeedItem) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, item); err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(b.String())
+	if text == "" {
+		return "", fmt.Errorf("template %s rendered an empty message", t.tmpl.Name())
+	}
+	return text, nil
+}
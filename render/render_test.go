@@ -0,0 +1,120 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		expectError bool
+	}{
+		{"fields and helpers", `{{ .Title }} {{ date "2006-01-02" .Published }} {{ .Summary | plain | truncate 20 }}`, false},
+		{"syntax error", `{{ .Title `, true},
+		{"unknown field", `{{ .Nope }}`, true},
+		{"unknown function", `{{ shout .Title }}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("test", tt.text)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	item := rss.FeedItem{
+		Title:     "Release v1.2 & notes",
+		Link:      "http://example.com/v1.2",
+		Published: time.Date(2025, 7, 25, 15, 0, 0, 0, time.UTC),
+		FeedTitle: "Releases",
+		Summary:   "<p>Fixes <b>many</b> bugs in the parser and the scheduler.</p>",
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "terse link",
+			text:     `<{{ .Link }}|{{ escape .Title }}>`,
+			expected: "<http://example.com/v1.2|Release v1.2 &amp; notes>",
+		},
+		{
+			name:     "excerpt",
+			text:     "*{{ .FeedTitle }}* ({{ date \"Jan 2\" .Published }})\n{{ .Summary | plain | truncate 30 }}",
+			expected: "*Releases* (Jul 25)\nFixes many bugs in the parser…",
+		},
+		{
+			name:     "mrkdwn summary",
+			text:     `{{ mrkdwn .Summary }}`,
+			expected: "Fixes *many* bugs in the parser and the scheduler.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse("test", tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := tmpl.Execute(item)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Execute() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("empty output", func(t *testing.T) {
+		tmpl, err := Parse("test", `{{ if false }}x{{ end }}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tmpl.Execute(item); err == nil {
+			t.Error("Expected error for empty message")
+		}
+	})
+}
+
+func TestHTMLToMrkdwn(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"inline", "<p>Some <strong>bold</strong>, <em>italic</em> and <code>code</code></p>", "Some *bold*, _italic_ and `code`"},
+		{"links", `Read <a href="http://example.com">the post</a>`, "Read <http://example.com|the post>"},
+		{"paragraphs", "<p>One</p>\n<p>Two</p>", "One\n\nTwo"},
+		{"lists", "<ul><li>First</li><li>Second</li></ul>", "• First\n• Second"},
+		{"escapes", "<p>a &lt; b &amp;&amp; c</p>", "a &lt; b &amp;&amp; c"},
+		{"preformatted", "<pre>x  := 1\n  y</pre>", "```x  := 1\n  y```"},
+		{"scripts", "<script>alert(1)</script>Text", "Text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTMLToMrkdwn(tt.input)
+			if got != tt.expected {
+				t.Errorf("HTMLToMrkdwn() = %q, want %q", got, tt.expected)
+			}
+			if strings.Contains(got, "\n\n\n") {
+				t.Errorf("Expected blank lines to be collapsed, got %q", got)
+			}
+		})
+	}
+}
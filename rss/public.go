@@ -0,0 +1,51 @@
+package rss
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// publicClient fetches feeds for requests with PublicOnly set. Its dialer
+// checks the address each connection is actually made to, so redirects and
+// DNS answers that change after a URL was checked can't lead it to internal
+// services.
+var publicClient = &http.Client{Transport: publicTransport()}
+
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if !allowDial(address) {
+				return fmt.Errorf("refusing to connect to non-public address %s", address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the dialer would only ever see the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// allowDial reports whether publicClient may connect to address, an IP and
+// port. Tests replace it so local test servers can pose as public hosts.
+var allowDial = func(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && IsPublic(ip)
+}
+
+// IsPublic reports whether ip is an address on the public internet, rather
+// than loopback, private, link-local, multicast or unspecified.
+func IsPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -0,0 +1,72 @@
+package rss
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchFeedPublicOnly(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss version="2.0"><channel><title>Internal</title>
+			<item><title>Secret</title><link>http://10.0.0.5/secret</link></item>
+		</channel></rss>`))
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/latest/meta-data", http.StatusFound)
+	}))
+	defer public.Close()
+
+	// Both servers listen on loopback; only the redirecting one counts as public
+	publicAddr := public.Listener.Addr().String()
+	defer func(allow func(string) bool) { allowDial = allow }(allowDial)
+	allowDial = func(address string) bool { return address == publicAddr }
+
+	t.Run("redirect to loopback", func(t *testing.T) {
+		_, err := FetchFeed(context.Background(), Request{URL: public.URL, PublicOnly: true})
+		if err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Errorf("Expected the redirect to be refused, got %v", err)
+		}
+	})
+
+	t.Run("loopback directly", func(t *testing.T) {
+		_, err := FetchFeed(context.Background(), Request{URL: internal.URL, PublicOnly: true})
+		if err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Errorf("Expected the connection to be refused, got %v", err)
+		}
+	})
+
+	t.Run("trusted feeds follow the redirect", func(t *testing.T) {
+		result, err := FetchFeed(context.Background(), Request{URL: public.URL})
+		if err != nil || len(result.Items) != 1 {
+			t.Errorf("Expected the internal feed, got %+v, %v", result.Items, err)
+		}
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -1,47 +1,176 @@
 package rss
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
 )
 
+const userAgent = "slack-rss-feed-manager/1.0"
+
 type FeedItem struct {
+	// ID identifies the item across fetches: the GUID when the feed provides
+	// one, otherwise the link.
+	ID        string
 	Title     string
 	Link      string
 	Published time.Time
 	FeedTitle string
+	Author    string
+	// Summary is the item's description as published, which may contain HTML.
+	Summary    string
+	ImageURL   string
+	Categories []string
+}
+
+// CacheInfo holds the HTTP validators returned by a previous fetch. They are
+// sent back as If-None-Match / If-Modified-Since so unchanged feeds can be
+// answered with 304 Not Modified.
+type CacheInfo struct {
+	ETag         string
+	LastModified string
+}
+
+// Request describes a feed fetch.
+type Request struct {
+	URL string
+	// Headers are extra HTTP headers to send, e.g. for feeds behind auth.
+	Headers map[string]string
+	Cache   CacheInfo
+	// PublicOnly refuses connections to addresses that aren't public, also
+	// after redirects, for feeds whose URL comes from untrusted users.
+	PublicOnly bool
 }
 
-func FetchFeed(url string, lastUpdated time.Time) ([]FeedItem, time.Time, error) {
-	fp := gofeed.NewParser()
-	feed, err := fp.ParseURL(url)
+// FetchResult holds every item currently in the feed. Deciding which of them
+// are new is left to the caller. Items without a date have a zero Published.
+// StatusCode is the HTTP status of the response, and is also set when
+// FetchFeed fails after a response arrived.
+type FetchResult struct {
+	Items       []FeedItem
+	Cache       CacheInfo
+	NotModified bool
+	StatusCode  int
+}
+
+// FetchFeed downloads and parses the requested feed. The request is abandoned
+// when ctx is cancelled or its deadline passes.
+func FetchFeed(ctx context.Context, request Request) (FetchResult, error) {
+	cache := request.Cache
+	result := FetchResult{Cache: cache}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request.URL, nil)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
+
+	client := http.DefaultClient
+	if request.PublicOnly {
+		client = publicClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, lastUpdated, err
+		return result, err
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+
+	// The body is still being read here, so a timeout can also surface as a parse error
+	feed, err := gofeed.NewParser().Parse(resp.Body)
+	if err != nil && ctx.Err() != nil {
+		return result, ctx.Err()
 	}
-	var items []FeedItem
-	latest := lastUpdated
+	if err != nil {
+		return result, err
+	}
+	result.Cache = CacheInfo{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
 	for _, item := range feed.Items {
+		feedItem := FeedItem{
+			ID:         itemID(item),
+			Title:      item.Title,
+			Link:       item.Link,
+			FeedTitle:  feed.Title,
+			Author:     itemAuthor(item),
+			Summary:    item.Description,
+			ImageURL:   itemImage(item),
+			Categories: item.Categories,
+		}
+		if feedItem.Summary == "" {
+			feedItem.Summary = item.Content
+		}
 		pubTime := item.PublishedParsed
 		if pubTime == nil {
 			pubTime = item.UpdatedParsed
 		}
-		if pubTime != nil && pubTime.After(lastUpdated) {
-			items = append(items, FeedItem{
-				Title:     item.Title,
-				Link:      item.Link,
-				Published: *pubTime,
-				FeedTitle: feed.Title,
-			})
-			if pubTime.After(latest) {
-				latest = *pubTime
-			}
+		if pubTime != nil {
+			feedItem.Published = *pubTime
+		}
+		result.Items = append(result.Items, feedItem)
+	}
+	return result, nil
+}
+
+func itemID(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Title
+}
+
+func itemAuthor(item *gofeed.Item) string {
+	var names []string
+	for _, author := range item.Authors {
+		if author != nil && author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func itemImage(item *gofeed.Item) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+	// Atom feeds often carry thumbnails as media:thumbnail, which gofeed leaves as an extension
+	for _, thumb := range item.Extensions["media"]["thumbnail"] {
+		if url := thumb.Attrs["url"]; url != "" {
+			return url
 		}
 	}
-	return items, latest, nil
+	return ""
 }
 
+// FormatItem renders an item as plain text. It is used as the notification
+// fallback for richer message formats.
 func FormatItem(item FeedItem) string {
 	return fmt.Sprintf("New post from %s: %s\n%s", item.FeedTitle, item.Title, item.Link)
 }
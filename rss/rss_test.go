@@ -1,6 +1,8 @@
 package rss
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -16,6 +18,7 @@ func TestFetchFeed(t *testing.T) {
 		<feed xmlns="http://www.w3.org/2005/Atom">
 			<title>Test Feed</title>
 			<entry>
+				<id>urn:post1</id>
 				<title>Test Post 1</title>
 				<link href="http://example.com/post1"/>
 				<updated>2024-03-01T12:00:00Z</updated>
@@ -29,46 +32,98 @@ func TestFetchFeed(t *testing.T) {
 	}))
 	defer server.Close()
 
-	t.Run("fetch new posts", func(t *testing.T) {
-		lastUpdated := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
-		items, newLastUpdated, err := FetchFeed(server.URL, lastUpdated)
+	t.Run("fetch all posts", func(t *testing.T) {
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
 		if err != nil {
 			t.Fatalf("FetchFeed() error = %v", err)
 		}
 
-		// Should find both posts
-		if len(items) != 2 {
-			t.Errorf("Expected 2 items, got %d", len(items))
+		if len(result.Items) != 2 {
+			t.Fatalf("Expected 2 items, got %d", len(result.Items))
 		}
 
-		// Check last updated time
 		expectedTime := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
-		if !newLastUpdated.Equal(expectedTime) {
-			t.Errorf("Expected last updated %v, got %v", expectedTime, newLastUpdated)
+		if !result.Items[1].Published.Equal(expectedTime) {
+			t.Errorf("Expected published %v, got %v", expectedTime, result.Items[1].Published)
 		}
 	})
 
-	t.Run("no new posts", func(t *testing.T) {
-		// Set lastUpdated to after both posts
-		lastUpdated := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
-		items, newLastUpdated, err := FetchFeed(server.URL, lastUpdated)
+	t.Run("item IDs", func(t *testing.T) {
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
 		if err != nil {
 			t.Fatalf("FetchFeed() error = %v", err)
 		}
 
-		if len(items) != 0 {
-			t.Errorf("Expected 0 items, got %d", len(items))
+		// Entries with an <id> use it, others fall back to the link
+		if result.Items[0].ID != "urn:post1" {
+			t.Errorf("Expected ID urn:post1, got %q", result.Items[0].ID)
 		}
+		if result.Items[1].ID != "http://example.com/post2" {
+			t.Errorf("Expected ID to fall back to link, got %q", result.Items[1].ID)
+		}
+	})
+
+	t.Run("item details", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+			<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+				<title>Detailed Feed</title>
+				<entry>
+					<title>Detailed Post</title>
+					<link href="http://example.com/detailed"/>
+					<updated>2024-03-01T12:00:00Z</updated>
+					<author><name>Jane Doe</name></author>
+					<summary type="html">&lt;p&gt;A summary&lt;/p&gt;</summary>
+					<media:thumbnail url="http://example.com/thumb.png"/>
+				</entry>
+			</feed>`))
+		}))
+		defer server.Close()
+
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
+		if err != nil {
+			t.Fatalf("FetchFeed() error = %v", err)
+		}
+		item := result.Items[0]
+		if item.Author != "Jane Doe" {
+			t.Errorf("Expected author Jane Doe, got %q", item.Author)
+		}
+		if item.Summary != "<p>A summary</p>" {
+			t.Errorf("Expected HTML summary, got %q", item.Summary)
+		}
+		if item.ImageURL != "http://example.com/thumb.png" {
+			t.Errorf("Expected thumbnail URL, got %q", item.ImageURL)
+		}
+	})
+
+	t.Run("undated posts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+			<rss version="2.0"><channel>
+				<title>Undated Feed</title>
+				<item><title>Undated Post</title><link>http://example.com/undated</link></item>
+			</channel></rss>`))
+		}))
+		defer server.Close()
 
-		if !newLastUpdated.Equal(lastUpdated) {
-			t.Errorf("Expected last updated to remain %v, got %v", lastUpdated, newLastUpdated)
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
+		if err != nil {
+			t.Fatalf("FetchFeed() error = %v", err)
+		}
+		if len(result.Items) != 1 {
+			t.Fatalf("Expected undated item to be returned, got %d items", len(result.Items))
+		}
+		if !result.Items[0].Published.IsZero() {
+			t.Errorf("Expected zero published time, got %v", result.Items[0].Published)
 		}
 	})
 }
 
 func TestFetchFeedErrors(t *testing.T) {
 	t.Run("invalid URL", func(t *testing.T) {
-		_, _, err := FetchFeed("http://invalid-url", time.Now())
+		_, err := FetchFeed(context.Background(), Request{URL: "http://invalid-url"})
 		if err == nil {
 			t.Error("Expected error for invalid URL, got nil")
 		}
@@ -81,11 +136,138 @@ func TestFetchFeedErrors(t *testing.T) {
 		}))
 		defer server.Close()
 
-		_, _, err := FetchFeed(server.URL, time.Now())
+		_, err := FetchFeed(context.Background(), Request{URL: server.URL})
 		if err == nil {
 			t.Error("Expected error for invalid feed content, got nil")
 		}
 	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gone", http.StatusGone)
+		}))
+		defer server.Close()
+
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
+		if err == nil {
+			t.Error("Expected error for HTTP 410, got nil")
+		}
+		if result.StatusCode != http.StatusGone {
+			t.Errorf("StatusCode = %d, want %d", result.StatusCode, http.StatusGone)
+		}
+	})
+}
+
+func TestFetchFeedConditional(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Fri, 01 Mar 2024 13:00:00 GMT"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<feed xmlns="http://www.w3.org/2005/Atom">
+			<title>Test Feed</title>
+			<entry>
+				<title>Test Post 1</title>
+				<link href="http://example.com/post1"/>
+				<updated>2024-03-01T12:00:00Z</updated>
+			</entry>
+		</feed>`))
+	}))
+	defer server.Close()
+
+	t.Run("returns validators", func(t *testing.T) {
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL})
+		if err != nil {
+			t.Fatalf("FetchFeed() error = %v", err)
+		}
+		if result.NotModified {
+			t.Error("Expected full response, got not modified")
+		}
+		if result.Cache.ETag != etag || result.Cache.LastModified != lastModified {
+			t.Errorf("Expected validators %q/%q, got %+v", etag, lastModified, result.Cache)
+		}
+		if len(result.Items) != 1 {
+			t.Errorf("Expected 1 item, got %d", len(result.Items))
+		}
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		cache := CacheInfo{ETag: etag, LastModified: lastModified}
+		result, err := FetchFeed(context.Background(), Request{URL: server.URL, Cache: cache})
+		if err != nil {
+			t.Fatalf("FetchFeed() error = %v", err)
+		}
+		if !result.NotModified {
+			t.Error("Expected not modified result")
+		}
+		if len(result.Items) != 0 {
+			t.Errorf("Expected 0 items, got %d", len(result.Items))
+		}
+		if result.Cache != cache {
+			t.Errorf("Expected cache to be unchanged, got %+v", result.Cache)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		if _, err := FetchFeed(context.Background(), Request{URL: server.URL}); err == nil {
+			t.Error("Expected error for 429 response, got nil")
+		}
+	})
+}
+
+func TestFetchFeedHeaders(t *testing.T) {
+	var gotAuth, gotAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<rss version="2.0"><channel><title>Private</title></channel></rss>`))
+	}))
+	defer server.Close()
+
+	_, err := FetchFeed(context.Background(), Request{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected Authorization header to be sent, got %q", gotAuth)
+	}
+	if gotAgent != userAgent {
+		t.Errorf("Expected default User-Agent, got %q", gotAgent)
+	}
+}
+
+func TestFetchFeedTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := FetchFeed(ctx, Request{URL: server.URL})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
 }
 
 func TestFormatItem(t *testing.T) {
@@ -0,0 +1,59 @@
+package rss
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// PlainText strips HTML markup from s and collapses whitespace. Feed summaries
+// are frequently HTML, and plain text is the safest thing to show in a message.
+func PlainText(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.StartTagToken:
+			if name, _ := z.TagName(); isInvisible(string(name)) {
+				skip++
+			}
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			if name, _ := z.TagName(); isInvisible(string(name)) && skip > 0 {
+				skip--
+			}
+			b.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			b.WriteByte(' ')
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		}
+	}
+}
+
+func isInvisible(tag string) bool {
+	return tag == "script" || tag == "style"
+}
+
+// Truncate shortens s to at most n runes, cutting at a word boundary where
+// possible and marking the cut with an ellipsis.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	cut := string(runes[:n-1])
+	// Back up to the last space unless the cut already falls between words
+	if i := strings.LastIndexByte(cut, ' '); runes[n-1] != ' ' && i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
@@ -0,0 +1,46 @@
+package rss
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "  already   plain\ntext ", "already plain text"},
+		{"markup", "<p>Hello <a href=\"#\">world</a></p><p>Again</p>", "Hello world Again"},
+		{"entities", "Fish &amp; chips", "Fish & chips"},
+		{"scripts", "<script>alert(1)</script>Visible<style>p{}</style>", "Visible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.input); got != tt.expected {
+				t.Errorf("PlainText() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		expected string
+	}{
+		{"short", "short text", 20, "short text"},
+		{"word boundary", "the quick brown fox jumps", 16, "the quick brown…"},
+		{"no spaces", "abcdefghij", 5, "abcd…"},
+		{"multibyte", "héllo wörld", 7, "héllo…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.input, tt.n); got != tt.expected {
+				t.Errorf("Truncate() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
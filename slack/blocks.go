@@ -0,0 +1,149 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+
+	"slack-rss-feed-manager/rss"
+)
+
+// Slack rejects blocks whose text exceeds these lengths.
+const (
+	maxHeaderLength  = 150
+	maxSummaryLength = 300
+	maxSectionLength = 3000
+)
+
+// MaxDigestItems is the most items a digest can list while staying within
+// Slack's limit of 50 blocks per message.
+const MaxDigestItems = 40
+
+// maxDigestTitleLength shortens item titles in digests so that lists stay
+// readable.
+const maxDigestTitleLength = 100
+
+// ItemMessage builds the Block Kit message for a feed item: a header with the
+// feed title, the item title as a link (with the thumbnail alongside when the
+// item has one), an author and date line, and a short plain-text excerpt.
+func ItemMessage(item rss.FeedItem) Message {
+	var blocks []slack.Block
+
+	if item.FeedTitle != "" {
+		header := slack.NewTextBlockObject(slack.PlainTextType, rss.Truncate(item.FeedTitle, maxHeaderLength), false, false)
+		blocks = append(blocks, slack.NewHeaderBlock(header))
+	}
+
+	title := "*" + Escape(item.Title) + "*"
+	if item.Link != "" {
+		title = "*<" + item.Link + "|" + Escape(item.Title) + ">*"
+	}
+	var accessory *slack.Accessory
+	if item.ImageURL != "" {
+		// Slack rejects images without alt text
+		alt := item.Title
+		if alt == "" {
+			alt = item.FeedTitle
+		}
+		if alt == "" {
+			alt = "thumbnail"
+		}
+		accessory = slack.NewAccessory(slack.NewImageBlockElement(item.ImageURL, alt))
+	}
+	blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, title, false, false), nil, accessory))
+
+	var meta []slack.MixedElement
+	if item.Author != "" {
+		meta = append(meta, slack.NewTextBlockObject(slack.MarkdownType, "By "+Escape(item.Author), false, false))
+	}
+	if !item.Published.IsZero() {
+		meta = append(meta, slack.NewTextBlockObject(slack.PlainTextType, item.Published.Format("Jan 2, 2006"), false, false))
+	}
+	if len(meta) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", meta...))
+	}
+
+	if summary := rss.Truncate(rss.PlainText(item.Summary), maxSummaryLength); summary != "" {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, Escape(summary), false, false), nil, nil))
+	}
+
+	return Message{Text: rss.FormatItem(item), Blocks: blocks}
+}
+
+// EditMessage builds the reply noting that item changed since it was posted
+// as previous: renamed, moved to a new link, or otherwise edited.
+func EditMessage(previous, item rss.FeedItem) Message {
+	title := Escape(item.Title)
+	if item.Link != "" {
+		title = "<" + item.Link + "|" + title + ">"
+	}
+	switch {
+	case previous.Title != item.Title:
+		return Message{Text: fmt.Sprintf(":pencil2: Renamed from \"%s\" to %s", Escape(previous.Title), title)}
+	case previous.Link != item.Link:
+		return Message{Text: ":pencil2: Moved to " + title}
+	default:
+		return Message{Text: ":pencil2: Updated: " + title}
+	}
+}
+
+// DigestMessage builds a message listing items grouped by feed, in the order
+// the feeds first appear, and noting how many more items were left out.
+func DigestMessage(items []rss.FeedItem, more int) Message {
+	total := len(items) + more
+	summary := fmt.Sprintf("%d new items", total)
+	if total == 1 {
+		summary = "1 new item"
+	}
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, summary, false, false)),
+	}
+
+	var feeds []string
+	lines := make(map[string][]string)
+	for _, item := range items {
+		if _, ok := lines[item.FeedTitle]; !ok {
+			feeds = append(feeds, item.FeedTitle)
+		}
+		title := Escape(rss.Truncate(item.Title, maxDigestTitleLength))
+		if item.Link != "" {
+			title = "<" + item.Link + "|" + title + ">"
+		}
+		lines[item.FeedTitle] = append(lines[item.FeedTitle], "• "+title)
+	}
+	for _, feed := range feeds {
+		text := ""
+		if feed != "" {
+			text = "*" + Escape(rss.Truncate(feed, maxHeaderLength)) + "*"
+		}
+		// Long lists are split over several sections
+		for _, line := range lines[feed] {
+			if text != "" && len(text)+1+len(line) > maxSectionLength {
+				blocks = append(blocks, markdownSection(text))
+				text = ""
+			}
+			if text != "" {
+				text += "\n"
+			}
+			text += line
+		}
+		blocks = append(blocks, markdownSection(text))
+	}
+
+	if more > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf("and %d more", more), false, false)))
+	}
+	return Message{Text: summary, Blocks: blocks}
+}
+
+func markdownSection(text string) *slack.SectionBlock {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape escapes the characters Slack treats as control sequences in mrkdwn.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,192 @@
+package slack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestItemMessage(t *testing.T) {
+	item := rss.FeedItem{
+		Title:     "Tips & Tricks",
+		Link:      "http://example.com/post",
+		Published: time.Date(2025, 7, 25, 15, 0, 0, 0, time.UTC),
+		FeedTitle: "Example Blog",
+		Author:    "Jane Doe",
+		Summary:   "<p>First paragraph with <b>bold</b> text.</p>",
+		ImageURL:  "http://example.com/thumb.png",
+	}
+
+	msg := ItemMessage(item)
+
+	if msg.Text != rss.FormatItem(item) {
+		t.Errorf("Expected plain text fallback %q, got %q", rss.FormatItem(item), msg.Text)
+	}
+	if len(msg.Blocks) != 4 {
+		t.Fatalf("Expected 4 blocks, got %d", len(msg.Blocks))
+	}
+
+	header, ok := msg.Blocks[0].(*slack.HeaderBlock)
+	if !ok || header.Text.Text != "Example Blog" {
+		t.Errorf("Expected header with feed title, got %#v", msg.Blocks[0])
+	}
+
+	title, ok := msg.Blocks[1].(*slack.SectionBlock)
+	if !ok {
+		t.Fatalf("Expected title section, got %#v", msg.Blocks[1])
+	}
+	if title.Text.Text != "*<http://example.com/post|Tips &amp; Tricks>*" {
+		t.Errorf("Unexpected title text %q", title.Text.Text)
+	}
+	if title.Accessory == nil || title.Accessory.ImageElement == nil || title.Accessory.ImageElement.ImageURL != item.ImageURL {
+		t.Error("Expected thumbnail accessory")
+	}
+
+	data, err := json.Marshal(msg.Blocks[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "By Jane Doe") || !strings.Contains(string(data), "Jul 25, 2025") {
+		t.Errorf("Expected author and date in context block, got %s", data)
+	}
+
+	summary, ok := msg.Blocks[3].(*slack.SectionBlock)
+	if !ok || summary.Text.Text != "First paragraph with bold text." {
+		t.Errorf("Expected plain text summary, got %#v", msg.Blocks[3])
+	}
+}
+
+func TestItemMessageMinimal(t *testing.T) {
+	msg := ItemMessage(rss.FeedItem{Title: "Just a title"})
+
+	if len(msg.Blocks) != 1 {
+		t.Fatalf("Expected only the title block, got %d blocks", len(msg.Blocks))
+	}
+	title := msg.Blocks[0].(*slack.SectionBlock)
+	if title.Text.Text != "*Just a title*" || title.Accessory != nil {
+		t.Errorf("Unexpected title block %#v", title)
+	}
+}
+
+func TestItemMessageImageAltText(t *testing.T) {
+	tests := []struct {
+		item rss.FeedItem
+		want string
+	}{
+		{rss.FeedItem{Title: "Hello", FeedTitle: "Blog"}, "Hello"},
+		{rss.FeedItem{FeedTitle: "Blog"}, "Blog"},
+		{rss.FeedItem{}, "thumbnail"},
+	}
+	for _, tt := range tests {
+		tt.item.ImageURL = "http://example.com/thumb.png"
+		var image *slack.ImageBlockElement
+		for _, block := range ItemMessage(tt.item).Blocks {
+			if section, ok := block.(*slack.SectionBlock); ok && section.Accessory != nil {
+				image = section.Accessory.ImageElement
+			}
+		}
+		if image == nil || image.AltText != tt.want {
+			t.Errorf("Expected alt text %q for %+v, got %#v", tt.want, tt.item, image)
+		}
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	previous := rss.FeedItem{Title: "Draft", Link: "http://example.com/draft"}
+	tests := []struct {
+		name string
+		item rss.FeedItem
+		want string
+	}{
+		{
+			name: "renamed",
+			item: rss.FeedItem{Title: "Q&A", Link: "http://example.com/draft"},
+			want: `:pencil2: Renamed from "Draft" to <http://example.com/draft|Q&amp;A>`,
+		},
+		{
+			name: "moved",
+			item: rss.FeedItem{Title: "Draft", Link: "http://example.com/final"},
+			want: ":pencil2: Moved to <http://example.com/final|Draft>",
+		},
+		{
+			name: "edited",
+			item: rss.FeedItem{Title: "Draft", Link: "http://example.com/draft", Summary: "Rewritten"},
+			want: ":pencil2: Updated: <http://example.com/draft|Draft>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EditMessage(previous, tt.item).Text; got != tt.want {
+				t.Errorf("EditMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestMessage(t *testing.T) {
+	items := []rss.FeedItem{
+		{Title: "First", Link: "http://example.com/1", FeedTitle: "Blog"},
+		{Title: "News & views", Link: "http://news.example.com/1", FeedTitle: "News"},
+		{Title: "Second", Link: "http://example.com/2", FeedTitle: "Blog"},
+	}
+
+	msg := DigestMessage(items, 2)
+
+	if msg.Text != "5 new items" {
+		t.Errorf("Unexpected text %q", msg.Text)
+	}
+	if len(msg.Blocks) != 4 {
+		t.Fatalf("Expected header, two feed sections and overflow, got %d blocks", len(msg.Blocks))
+	}
+	if header := msg.Blocks[0].(*slack.HeaderBlock); header.Text.Text != "5 new items" {
+		t.Errorf("Unexpected header %q", header.Text.Text)
+	}
+	blog := msg.Blocks[1].(*slack.SectionBlock).Text.Text
+	if blog != "*Blog*\n• <http://example.com/1|First>\n• <http://example.com/2|Second>" {
+		t.Errorf("Unexpected Blog section %q", blog)
+	}
+	news := msg.Blocks[2].(*slack.SectionBlock).Text.Text
+	if news != "*News*\n• <http://news.example.com/1|News &amp; views>" {
+		t.Errorf("Unexpected News section %q", news)
+	}
+	data, err := json.Marshal(msg.Blocks[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "and 2 more") {
+		t.Errorf("Expected overflow note, got %s", data)
+	}
+}
+
+func TestDigestMessageLimits(t *testing.T) {
+	if msg := DigestMessage([]rss.FeedItem{{Title: "Only"}}, 0); msg.Text != "1 new item" || len(msg.Blocks) != 2 {
+		t.Errorf("Unexpected message for a single item: %q with %d blocks", msg.Text, len(msg.Blocks))
+	}
+
+	// Long lists are split so that no section exceeds Slack's limit
+	link := "http://example.com/" + strings.Repeat("x", 200)
+	var items []rss.FeedItem
+	for range MaxDigestItems {
+		items = append(items, rss.FeedItem{Title: strings.Repeat("word ", 40), Link: link, FeedTitle: "Blog"})
+	}
+	msg := DigestMessage(items, 0)
+	if len(msg.Blocks) > 50 {
+		t.Errorf("Expected at most 50 blocks, got %d", len(msg.Blocks))
+	}
+	lines := 0
+	for _, block := range msg.Blocks[1:] {
+		text := block.(*slack.SectionBlock).Text.Text
+		if len(text) > maxSectionLength {
+			t.Errorf("Section of %d characters exceeds the limit", len(text))
+		}
+		lines += strings.Count(text, "• ")
+	}
+	if lines != MaxDigestItems {
+		t.Errorf("Expected all %d items listed, got %d", MaxDigestItems, lines)
+	}
+}
@@ -0,0 +1,59 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	form := url.Values{
+		"command":      {"/rss"},
+		"text":         {"subscribe https://example.com/feed.xml"},
+		"channel_id":   {"C123"},
+		"channel_name": {"general"},
+		"user_id":      {"U123"},
+		"user_name":    {"sam"},
+	}
+	body := form.Encode()
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/slack/command", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		r := newRequest()
+		SignRequest(r, []byte(body), "secret", time.Now())
+		cmd, err := ParseCommand(r, "secret")
+		if err != nil {
+			t.Fatalf("ParseCommand() error = %v", err)
+		}
+		want := Command{Name: "/rss", Text: "subscribe https://example.com/feed.xml", ChannelID: "C123", ChannelName: "general", UserID: "U123", UserName: "sam"}
+		if cmd != want {
+			t.Errorf("ParseCommand() = %+v, want %+v", cmd, want)
+		}
+	})
+
+	tests := []struct {
+		name string
+		sign func(r *http.Request)
+	}{
+		{"unsigned", func(r *http.Request) {}},
+		{"wrong secret", func(r *http.Request) { SignRequest(r, []byte(body), "other", time.Now()) }},
+		{"tampered body", func(r *http.Request) { SignRequest(r, []byte(body+"x"), "secret", time.Now()) }},
+		{"expired", func(r *http.Request) { SignRequest(r, []byte(body), "secret", time.Now().Add(-10*time.Minute)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest()
+			tt.sign(r)
+			if _, err := ParseCommand(r, "secret"); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Defaults used when the queue options are not set.
+const (
+	DefaultPostInterval = time.Second
+	DefaultBackoff      = 2 * time.Second
+	// maxWait caps a single wait, so a huge Retry-After can't stall a run.
+	maxWait = 2 * time.Minute
+)
+
+// Poster posts a single message, returning the channel's ID and the message's
+// timestamp, and updates messages posted before.
+type Poster interface {
+	PostMessage(channel string, msg Message) (string, string, error)
+	UpdateMessage(channelID, ts string, msg Message) error
+}
+
+type QueueOptions struct {
+	// PostInterval is the minimum time between posts to the same channel.
+	PostInterval time.Duration
+	// MaxRetries is how many times a failed post is retried.
+	MaxRetries int
+	// Backoff is the wait before the first retry of a transient error. It
+	// doubles with each further attempt.
+	Backoff time.Duration
+}
+
+// Failure is a message that could not be delivered after all retries.
+type Failure struct {
+	Channel string
+	Text    string
+	Err     error
+}
+
+// Queue sends messages through a Poster one at a time, spacing posts to the
+// same channel, waiting out rate limits for as long as Slack's Retry-After
+// asks, and retrying transient errors with exponential backoff. Messages that
+// still fail are returned as errors and kept for Failures. A queue serves a
+// single run: once its context is cancelled, waits end early and messages
+// that would have to wait fail instead.
+type Queue struct {
+	ctx     context.Context
+	poster  Poster
+	options QueueOptions
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	lastPost map[string]time.Time
+	failures []Failure
+}
+
+func NewQueue(ctx context.Context, poster Poster, options QueueOptions) *Queue {
+	if options.PostInterval <= 0 {
+		options.PostInterval = DefaultPostInterval
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+	return &Queue{
+		ctx:      ctx,
+		poster:   poster,
+		options:  options,
+		sleep:    sleep,
+		lastPost: make(map[string]time.Time),
+	}
+}
+
+func (q *Queue) PostMessage(channel string, msg Message) (string, string, error) {
+	var channelID, ts string
+	err := q.send(channel, msg, func() (err error) {
+		channelID, ts, err = q.poster.PostMessage(channel, msg)
+		return err
+	})
+	return channelID, ts, err
+}
+
+// UpdateMessage updates a message like PostMessage posts one. Updates are
+// spaced by channel ID, apart from the posts to the channel's name.
+func (q *Queue) UpdateMessage(channelID, ts string, msg Message) error {
+	return q.send(channelID, msg, func() error {
+		return q.poster.UpdateMessage(channelID, ts, msg)
+	})
+}
+
+// send runs attempt, which delivers msg to channel, until it succeeds or runs
+// out of retries.
+func (q *Queue) send(channel string, msg Message, attempt func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var err error
+	for n := 0; ; n++ {
+		if last, ok := q.lastPost[channel]; ok {
+			if wait := q.options.PostInterval - time.Since(last); wait > 0 {
+				if err = q.sleep(q.ctx, wait); err != nil {
+					break
+				}
+			}
+		}
+		err = attempt()
+		q.lastPost[channel] = time.Now()
+		if err == nil {
+			return nil
+		}
+
+		wait, retryable := q.retryDelay(err, n)
+		if !retryable || n >= q.options.MaxRetries {
+			break
+		}
+		log.Printf("Posting to %s failed, retrying in %v: %v", channel, wait, err)
+		if q.sleep(q.ctx, wait) != nil {
+			break
+		}
+	}
+
+	q.failures = append(q.failures, Failure{Channel: channel, Text: msg.Text, Err: err})
+	return err
+}
+
+// sleep waits for d, or returns ctx's error if it is cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Failures returns the messages that permanently failed so far.
+func (q *Queue) Failures() []Failure {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Failure(nil), q.failures...)
+}
+
+// retryDelay reports whether err is worth retrying and how long to wait first.
+func (q *Queue) retryDelay(err error, attempt int) (time.Duration, bool) {
+	var rateLimited *slack.RateLimitedError
+	if errors.As(err, &rateLimited) {
+		return min(max(rateLimited.RetryAfter, q.options.PostInterval), maxWait), true
+	}
+
+	backoff := min(q.options.Backoff<<attempt, maxWait)
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return backoff, retryable.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return backoff, true
+	}
+	return 0, false
+}
@@ -0,0 +1,192 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// fakePoster fails with the scripted errors in order, then succeeds.
+type fakePoster struct {
+	errs  []error
+	posts []string
+}
+
+func (f *fakePoster) PostMessage(channel string, msg Message) (string, string, error) {
+	if err := f.UpdateMessage(channel, "", msg); err != nil {
+		return "", "", err
+	}
+	return "C123", fmt.Sprintf("%d.000100", len(f.posts)), nil
+}
+
+func (f *fakePoster) UpdateMessage(channelID, ts string, msg Message) error {
+	f.posts = append(f.posts, channelID)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func newTestQueue(poster Poster, options QueueOptions) (*Queue, *[]time.Duration) {
+	q := NewQueue(context.Background(), poster, options)
+	var waits []time.Duration
+	q.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return q, &waits
+}
+
+func TestQueueRetries(t *testing.T) {
+	msg := Message{Text: "hello"}
+
+	t.Run("honors Retry-After", func(t *testing.T) {
+		poster := &fakePoster{errs: []error{&slack.RateLimitedError{RetryAfter: 30 * time.Second}}}
+		q, waits := newTestQueue(poster, QueueOptions{MaxRetries: 3})
+
+		channelID, ts, err := q.PostMessage("#general", msg)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if channelID != "C123" || ts != "2.000100" {
+			t.Errorf("Expected the timestamp of the successful attempt, got %q", ts)
+		}
+		if len(poster.posts) != 2 {
+			t.Errorf("Expected 2 attempts, got %d", len(poster.posts))
+		}
+		if len(*waits) == 0 || (*waits)[0] != 30*time.Second {
+			t.Errorf("Expected to wait 30s, got %v", *waits)
+		}
+	})
+
+	t.Run("backs off on transient errors", func(t *testing.T) {
+		poster := &fakePoster{errs: []error{
+			slack.StatusCodeError{Code: 503, Status: "503 Service Unavailable"},
+			slack.StatusCodeError{Code: 502, Status: "502 Bad Gateway"},
+		}}
+		// A tiny post interval keeps channel spacing out of the recorded waits
+		q, waits := newTestQueue(poster, QueueOptions{PostInterval: time.Nanosecond, MaxRetries: 3, Backoff: time.Second})
+
+		if _, _, err := q.PostMessage("#general", msg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(poster.posts) != 3 {
+			t.Errorf("Expected 3 attempts, got %d", len(poster.posts))
+		}
+		want := []time.Duration{time.Second, 2 * time.Second}
+		if len(*waits) != len(want) || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+			t.Errorf("Expected waits %v, got %v", want, *waits)
+		}
+	})
+
+	t.Run("zero max retries disables retries", func(t *testing.T) {
+		poster := &fakePoster{errs: []error{&slack.RateLimitedError{RetryAfter: time.Second}}}
+		q, _ := newTestQueue(poster, QueueOptions{MaxRetries: 0})
+
+		if _, _, err := q.PostMessage("#general", msg); err == nil {
+			t.Fatal("Expected error without retries")
+		}
+		if len(poster.posts) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", len(poster.posts))
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		errs := make([]error, 5)
+		for i := range errs {
+			errs[i] = slack.StatusCodeError{Code: 500, Status: "500 Internal Server Error"}
+		}
+		poster := &fakePoster{errs: errs}
+		q, _ := newTestQueue(poster, QueueOptions{MaxRetries: 2})
+
+		if _, _, err := q.PostMessage("#general", msg); err == nil {
+			t.Fatal("Expected error after retries")
+		}
+		if len(poster.posts) != 3 {
+			t.Errorf("Expected 3 attempts, got %d", len(poster.posts))
+		}
+		failures := q.Failures()
+		if len(failures) != 1 || failures[0].Channel != "#general" || failures[0].Text != "hello" {
+			t.Errorf("Expected failure to be reported, got %+v", failures)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		poster := &fakePoster{errs: []error{slack.SlackErrorResponse{Err: "channel_not_found"}}}
+		q, _ := newTestQueue(poster, QueueOptions{MaxRetries: 3})
+
+		_, _, err := q.PostMessage("#missing", msg)
+		if err == nil || !errors.As(err, new(slack.SlackErrorResponse)) {
+			t.Fatalf("Expected channel_not_found error, got %v", err)
+		}
+		if len(poster.posts) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", len(poster.posts))
+		}
+		if len(q.Failures()) != 1 {
+			t.Errorf("Expected 1 failure, got %d", len(q.Failures()))
+		}
+	})
+}
+
+func TestQueueSpacing(t *testing.T) {
+	poster := &fakePoster{}
+	q, waits := newTestQueue(poster, QueueOptions{PostInterval: time.Minute})
+
+	for _, channel := range []string{"#a", "#b", "#a"} {
+		if _, _, err := q.PostMessage(channel, Message{Text: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the second post to #a has to wait
+	if len(*waits) != 1 || (*waits)[0] <= 59*time.Second {
+		t.Errorf("Expected a single wait of about a minute, got %v", *waits)
+	}
+}
+
+func TestQueueUpdate(t *testing.T) {
+	poster := &fakePoster{errs: []error{&slack.RateLimitedError{RetryAfter: time.Second}}}
+	q, waits := newTestQueue(poster, QueueOptions{PostInterval: time.Minute, MaxRetries: 3})
+
+	if err := q.UpdateMessage("C123", "1.000100", Message{Text: "edited"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(poster.posts) != 2 || poster.posts[1] != "C123" {
+		t.Errorf("Expected the update to be retried, got %v", poster.posts)
+	}
+	// The retry waits out the post interval as well as Retry-After
+	if len(*waits) != 2 {
+		t.Errorf("Expected 2 waits, got %v", *waits)
+	}
+}
+
+func TestQueueCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	poster := &fakePoster{errs: []error{&slack.RateLimitedError{RetryAfter: time.Minute}}}
+	q := NewQueue(ctx, poster, QueueOptions{MaxRetries: 3})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, _, err := q.PostMessage("#general", Message{Text: "hi"})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancelling to cut the Retry-After wait short, took %v", elapsed)
+	}
+	var rateLimited *slack.RateLimitedError
+	if !errors.As(err, &rateLimited) || len(poster.posts) != 1 || len(q.Failures()) != 1 {
+		t.Errorf("Expected the rate limited post to fail without a retry, got %v after %d attempts", err, len(poster.posts))
+	}
+
+	// Waits between posts end early too
+	if _, _, err := q.PostMessage("#general", Message{Text: "again"}); err == nil || len(poster.posts) != 1 {
+		t.Errorf("Expected no post once the queue is cancelled, got %v", err)
+	}
+}
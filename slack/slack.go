@@ -14,14 +14,53 @@ func NewClient(token string) *Client {
 	return &Client{api: slack.New(token)}
 }
 
-func (c *Client) PostMessage(channel, text string) error {
+// Message is a message to post. Text is always sent and is what Slack shows in
+// notifications; Blocks, when present, are what clients render in the channel.
+type Message struct {
+	Text   string
+	Blocks []slack.Block
+	// ThreadTS, when set, posts the message as a reply in the thread of the
+	// message with that timestamp.
+	ThreadTS string
+}
+
+// PostMessage posts msg to channel and returns the ID of the channel and the
+// timestamp Slack assigned to the message, which together identify it for
+// replies and updates.
+func (c *Client) PostMessage(channel string, msg Message) (string, string, error) {
 	if channel == "" {
-		return errors.New("channel cannot be empty")
+		return "", "", errors.New("channel cannot be empty")
+	}
+	if msg.Text == "" {
+		return "", "", errors.New("message cannot be empty")
+	}
+
+	options := []slack.MsgOption{slack.MsgOptionText(msg.Text, false)}
+	if len(msg.Blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(msg.Blocks...))
 	}
-	if text == "" {
+	if msg.ThreadTS != "" {
+		options = append(options, slack.MsgOptionTS(msg.ThreadTS))
+	}
+	return c.api.PostMessage(channel, options...)
+}
+
+// UpdateMessage replaces the text and blocks of the message with timestamp ts
+// in the channel with ID channelID. Slack only accepts channel IDs here, not
+// names.
+func (c *Client) UpdateMessage(channelID, ts string, msg Message) error {
+	if channelID == "" || ts == "" {
+		return errors.New("channel and timestamp cannot be empty")
+	}
+	if msg.Text == "" {
 		return errors.New("message cannot be empty")
 	}
 
-	_, _, err := c.api.PostMessage(channel, slack.MsgOptionText(text, false))
+	// Without blocks the old ones would stay, so they are always replaced
+	blocks := msg.Blocks
+	if blocks == nil {
+		blocks = []slack.Block{}
+	}
+	_, _, _, err := c.api.UpdateMessage(channelID, ts, slack.MsgOptionText(msg.Text, false), slack.MsgOptionBlocks(blocks...))
 	return err
 }
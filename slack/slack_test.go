@@ -1,7 +1,12 @@
 package slack
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+
+	"github.com/slack-go/slack"
 )
 
 func TestNewClient(t *testing.T) {
@@ -37,7 +42,7 @@ func TestPostMessage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewClient("test-token") // Using real client for input validation
-			err := client.PostMessage(tt.channel, tt.message)
+			_, _, err := client.PostMessage(tt.channel, Message{Text: tt.message})
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -48,3 +53,54 @@ func TestPostMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestPostMessageThread(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "channel": "C123", "ts": "1721908800.000200"}`))
+	}))
+	defer server.Close()
+	client := &Client{api: slack.New("test-token", slack.OptionAPIURL(server.URL+"/"))}
+
+	channelID, ts, err := client.PostMessage("#general", Message{Text: "reply", ThreadTS: "1721908800.000100"})
+	if err != nil {
+		t.Fatalf("PostMessage() error = %v", err)
+	}
+	if channelID != "C123" || ts != "1721908800.000200" {
+		t.Errorf("Expected the channel ID and the posted message's timestamp, got %q %q", channelID, ts)
+	}
+	if form.Get("thread_ts") != "1721908800.000100" || form.Get("text") != "reply" {
+		t.Errorf("Unexpected request %v", form)
+	}
+}
+
+func TestUpdateMessage(t *testing.T) {
+	var path string
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		path, form = r.URL.Path, r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "channel": "C123", "ts": "1721908800.000100", "text": "edited"}`))
+	}))
+	defer server.Close()
+	client := &Client{api: slack.New("test-token", slack.OptionAPIURL(server.URL+"/"))}
+
+	if err := client.UpdateMessage("C123", "1721908800.000100", Message{Text: "edited"}); err != nil {
+		t.Fatalf("UpdateMessage() error = %v", err)
+	}
+	if path != "/chat.update" || form.Get("channel") != "C123" || form.Get("ts") != "1721908800.000100" || form.Get("text") != "edited" {
+		t.Errorf("Unexpected request to %s: %v", path, form)
+	}
+	// Blocks of the old message are cleared when the new one has none
+	if form.Get("blocks") != "[]" {
+		t.Errorf("Expected blocks to be replaced, got %q", form.Get("blocks"))
+	}
+
+	if err := client.UpdateMessage("", "1721908800.000100", Message{Text: "edited"}); err == nil {
+		t.Error("Expected error for a missing channel ID")
+	}
+}
@@ -0,0 +1,43 @@
+package state
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned by Lock when another process holds the lock.
+var ErrLocked = errors.New("state file is locked by another process")
+
+// FileLock is an advisory lock on a state file, held from Lock until Unlock.
+type FileLock struct {
+	file *os.File
+}
+
+// LockPath returns the lock file used for a state file.
+func LockPath(filePath string) string {
+	return filePath + ".lock"
+}
+
+// Lock takes an exclusive advisory lock on the state file so that two runs
+// can't both read and write it. It fails with ErrLocked instead of waiting if
+// the lock is already held. The lock is released when the process exits.
+func Lock(filePath string) (*FileLock, error) {
+	f, err := os.OpenFile(LockPath(filePath), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{file: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package state
+
+import "os"
+
+// Advisory locking is only implemented on Unix; elsewhere Lock always
+// succeeds and concurrent runs are not detected.
+
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
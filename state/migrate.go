@@ -0,0 +1,57 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentVersion is the schema version of the state file written by Save.
+const CurrentVersion = 1
+
+// A migration upgrades a decoded state file by one version, in place. It works
+// on the generic JSON form so that it keeps working however State changes
+// later.
+type migration func(doc map[string]any) error
+
+// migrations[i] upgrades a state file from version i to i+1. Version 0 is a
+// file written before the version was recorded. Append a migration and bump
+// CurrentVersion whenever a change to State can't be read from older files
+// as they are.
+var migrations = []migration{
+	// Unversioned files already have the version 1 layout: every field added
+	// to FeedState since the first release is optional.
+	func(doc map[string]any) error { return nil },
+}
+
+// migrate upgrades the state file data to the version of len(steps) and
+// returns the upgraded data and the version it started from.
+func migrate(data []byte, steps []migration) ([]byte, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if v, ok := doc["Version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, 0, fmt.Errorf("invalid state version %v", v)
+		}
+		version = int(f)
+	}
+	if version > len(steps) {
+		return nil, version, fmt.Errorf("state version %d is newer than this build supports (%d)", version, len(steps))
+	}
+	if version == len(steps) {
+		return data, version, nil
+	}
+
+	for v := version; v < len(steps); v++ {
+		if err := steps[v](doc); err != nil {
+			return nil, version, fmt.Errorf("failed to upgrade state from version %d: %w", v, err)
+		}
+		doc["Version"] = v + 1
+	}
+	upgraded, err := json.Marshal(doc)
+	return upgraded, version, err
+}
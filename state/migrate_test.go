@@ -0,0 +1,112 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadStateUpgradesUnversionedFile(t *testing.T) {
+	// A copy of state.json as written before the version was recorded
+	data, err := os.ReadFile("testdata/state_unversioned.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if s.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", s.Version, CurrentVersion)
+	}
+	feeds := s.Channels["tech-blog-alerts"].Feeds
+	if len(feeds) != 4 {
+		t.Fatalf("Expected 4 feeds, got %d", len(feeds))
+	}
+	want := time.Date(2025, 9, 26, 0, 0, 0, 0, time.UTC)
+	if got := feeds["https://go.dev/blog/feed.atom"].LastUpdated; !got.Equal(want) {
+		t.Errorf("LastUpdated = %v, want %v", got, want)
+	}
+
+	// Saving records the version, and the original is kept as the backup
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct{ Version int }
+	if err := json.Unmarshal(saved, &doc); err != nil || doc.Version != CurrentVersion {
+		t.Errorf("Expected saved file to have version %d, got %d (%v)", CurrentVersion, doc.Version, err)
+	}
+	backup, err := os.ReadFile(BackupPath(path))
+	if err != nil || string(backup) != string(data) {
+		t.Errorf("Expected the unversioned file as backup, got %v", err)
+	}
+}
+
+func TestLoadStateRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"Version": 99, "Channels": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadState(path)
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected error for newer version, got %v", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	steps := []migration{
+		func(doc map[string]any) error {
+			doc["Steps"] = "1"
+			return nil
+		},
+		func(doc map[string]any) error {
+			doc["Steps"] = doc["Steps"].(string) + "2"
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		fromVersion int
+		steps       string
+	}{
+		{"unversioned", `{}`, 0, "12"},
+		{"partly upgraded", `{"Version": 1, "Steps": "1"}`, 1, "12"},
+		{"current", `{"Version": 2, "Steps": "done"}`, 2, "done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, from, err := migrate([]byte(tt.input), steps)
+			if err != nil {
+				t.Fatalf("migrate() error = %v", err)
+			}
+			if from != tt.fromVersion {
+				t.Errorf("from version = %d, want %d", from, tt.fromVersion)
+			}
+			var doc struct {
+				Version int
+				Steps   string
+			}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Version != 2 || doc.Steps != tt.steps {
+				t.Errorf("Got version %d with steps %q, want version 2 with %q", doc.Version, doc.Steps, tt.steps)
+			}
+		})
+	}
+}
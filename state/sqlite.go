@@ -0,0 +1,418 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates a new database at the latest version.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS channels (
+	name        TEXT PRIMARY KEY,
+	last_digest TEXT NOT NULL DEFAULT '0001-01-01T00:00:00Z'
+);
+CREATE TABLE IF NOT EXISTS feeds (
+	channel       TEXT NOT NULL,
+	url           TEXT NOT NULL,
+	last_updated  TEXT NOT NULL,
+	etag          TEXT NOT NULL DEFAULT '',
+	last_modified TEXT NOT NULL DEFAULT '',
+	last_checked  TEXT NOT NULL,
+	next_due      TEXT NOT NULL,
+	pending       TEXT NOT NULL DEFAULT 'null',
+	failures      INTEGER NOT NULL DEFAULT 0,
+	last_error    TEXT NOT NULL DEFAULT '',
+	last_status   INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (channel, url)
+);
+CREATE TABLE IF NOT EXISTS seen_items (
+	channel  TEXT NOT NULL,
+	url      TEXT NOT NULL,
+	item_id  TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (channel, url, item_id)
+);
+CREATE TABLE IF NOT EXISTS deliveries (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel      TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	item_id      TEXT NOT NULL,
+	title        TEXT NOT NULL,
+	link         TEXT NOT NULL,
+	delivered_at TEXT NOT NULL,
+	hash         TEXT NOT NULL DEFAULT '',
+	channel_id   TEXT NOT NULL DEFAULT '',
+	ts           TEXT NOT NULL DEFAULT '',
+	thread       TEXT NOT NULL DEFAULT '',
+	UNIQUE (channel, url, item_id, delivered_at)
+);
+`
+
+// sqliteMigrations[i] upgrades a database from version i+1 to i+2. Append to
+// it, and update sqliteSchema to match, whenever the tables change.
+var sqliteMigrations = []string{
+	// 2: feed health
+	`ALTER TABLE feeds ADD COLUMN failures INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE feeds ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+	ALTER TABLE feeds ADD COLUMN last_status INTEGER NOT NULL DEFAULT 0;`,
+	// 3: digests
+	`ALTER TABLE channels ADD COLUMN last_digest TEXT NOT NULL DEFAULT '0001-01-01T00:00:00Z';`,
+	// 4: edits of posted items
+	`ALTER TABLE deliveries ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+	ALTER TABLE deliveries ADD COLUMN channel_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE deliveries ADD COLUMN ts TEXT NOT NULL DEFAULT '';
+	ALTER TABLE deliveries ADD COLUMN thread TEXT NOT NULL DEFAULT '';`,
+}
+
+// sqliteVersion is the schema version of the database, kept in its
+// user_version.
+var sqliteVersion = len(sqliteMigrations) + 1
+
+// SQLiteStore keeps state in an SQLite database. Only feeds that changed since
+// the last load or save are written, and the full delivery history is kept
+// while State only carries the most recent MaxDeliveries per feed.
+type SQLiteStore struct {
+	// db is nil for a read-only store of a database that doesn't exist yet
+	db       *sql.DB
+	readOnly bool
+	// saved holds the encoding of each feed as last loaded or saved, keyed by
+	// channel and URL, to find the feeds that changed.
+	saved map[[2]string]string
+}
+
+// OpenSQLite opens the database at path, creating it and its tables if needed.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if version > sqliteVersion {
+		db.Close()
+		return nil, fmt.Errorf("database %s has schema version %d, newer than this build supports (%d)", path, version, sqliteVersion)
+	}
+	if err := upgradeSQLite(db, version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set up tables in %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db, saved: make(map[[2]string]string)}, nil
+}
+
+// OpenSQLiteReadOnly opens the database at path for reading only. Unlike
+// OpenSQLite it never creates or upgrades the database: a missing one loads as
+// empty state, and one at an older schema version is refused until a
+// read-write open has upgraded it. Save always fails.
+func OpenSQLiteReadOnly(path string) (*SQLiteStore, error) {
+	store := &SQLiteStore{readOnly: true, saved: make(map[[2]string]string)}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	dsn := (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		db.Close()
+		return nil, err
+	}
+	switch {
+	case version == 0:
+		// An empty file, with no tables yet
+		db.Close()
+		return store, nil
+	case version != sqliteVersion:
+		db.Close()
+		return nil, fmt.Errorf("database %s has schema version %d and can only be read at version %d; open it for writing once to upgrade it", path, version, sqliteVersion)
+	}
+	store.db = db
+	return store, nil
+}
+
+// upgradeSQLite creates the tables of a new database, or brings those of an
+// older version up to date.
+func upgradeSQLite(db *sql.DB, version int) error {
+	if version == sqliteVersion {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if version == 0 {
+		if _, err := tx.Exec(sqliteSchema); err != nil {
+			return err
+		}
+	} else {
+		for v := version; v < sqliteVersion; v++ {
+			if _, err := tx.Exec(sqliteMigrations[v-1]); err != nil {
+				return fmt.Errorf("upgrading from version %d: %w", v, err)
+			}
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, sqliteVersion)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Load() (State, error) {
+	state := State{Version: CurrentVersion, Channels: make(map[string]ChannelState)}
+	if s.db == nil {
+		return state, nil
+	}
+
+	rows, err := s.db.Query(`SELECT name, last_digest FROM channels`)
+	if err != nil {
+		return State{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, lastDigest string
+		if err := rows.Scan(&name, &lastDigest); err != nil {
+			return State{}, err
+		}
+		ch := ChannelState{Feeds: make(map[string]FeedState)}
+		if ch.LastDigest, err = parseTime(lastDigest); err != nil {
+			return State{}, err
+		}
+		state.Channels[name] = ch
+	}
+	if err := rows.Err(); err != nil {
+		return State{}, err
+	}
+
+	feeds := make(map[[2]string]*FeedState)
+	rows, err = s.db.Query(`SELECT channel, url, last_updated, etag, last_modified, last_checked, next_due, pending, failures, last_error, last_status FROM feeds`)
+	if err != nil {
+		return State{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var channel, url, lastUpdated, lastChecked, nextDue, pending string
+		var fs FeedState
+		if err := rows.Scan(&channel, &url, &lastUpdated, &fs.ETag, &fs.LastModified, &lastChecked, &nextDue, &pending, &fs.Failures, &fs.LastError, &fs.LastStatus); err != nil {
+			return State{}, err
+		}
+		if fs.LastUpdated, err = parseTime(lastUpdated); err != nil {
+			return State{}, err
+		}
+		if fs.LastChecked, err = parseTime(lastChecked); err != nil {
+			return State{}, err
+		}
+		if fs.NextDue, err = parseTime(nextDue); err != nil {
+			return State{}, err
+		}
+		if err := json.Unmarshal([]byte(pending), &fs.Pending); err != nil {
+			return State{}, fmt.Errorf("pending items of %s: %w", url, err)
+		}
+		feeds[[2]string{channel, url}] = &fs
+	}
+	if err := rows.Err(); err != nil {
+		return State{}, err
+	}
+
+	rows, err = s.db.Query(`SELECT channel, url, item_id FROM seen_items ORDER BY channel, url, position`)
+	if err != nil {
+		return State{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var channel, url, id string
+		if err := rows.Scan(&channel, &url, &id); err != nil {
+			return State{}, err
+		}
+		if fs, ok := feeds[[2]string{channel, url}]; ok {
+			fs.SeenIDs = append(fs.SeenIDs, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return State{}, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT channel, url, item_id, title, link, delivered_at, hash, channel_id, ts, thread FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY channel, url ORDER BY id DESC) AS n FROM deliveries
+		) WHERE n <= ? ORDER BY channel, url, id`, MaxDeliveries)
+	if err != nil {
+		return State{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var channel, url, at string
+		var d Delivery
+		if err := rows.Scan(&channel, &url, &d.ItemID, &d.Title, &d.Link, &at, &d.Hash, &d.ChannelID, &d.TS, &d.Thread); err != nil {
+			return State{}, err
+		}
+		if d.At, err = parseTime(at); err != nil {
+			return State{}, err
+		}
+		if fs, ok := feeds[[2]string{channel, url}]; ok {
+			fs.Delivered = append(fs.Delivered, d)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return State{}, err
+	}
+
+	s.saved = make(map[[2]string]string, len(feeds))
+	for key, fs := range feeds {
+		ch, ok := state.Channels[key[0]]
+		if !ok {
+			ch = ChannelState{Feeds: make(map[string]FeedState)}
+			state.Channels[key[0]] = ch
+		}
+		ch.Feeds[key[1]] = *fs
+		s.saved[key] = encodeFeed(*fs)
+	}
+	return state, nil
+}
+
+// Save writes the feeds that changed since the last load or save, and removes
+// channels and feeds that are no longer in state. Delivery history is only
+// ever added to, apart from updating deliveries whose item was edited.
+func (s *SQLiteStore) Save(state *State) error {
+	if s.readOnly {
+		return errors.New("state database was opened read-only")
+	}
+	state.Version = CurrentVersion
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM channels`); err != nil {
+		return err
+	}
+	saved := make(map[[2]string]string)
+	for channel, ch := range state.Channels {
+		if _, err := tx.Exec(`INSERT INTO channels (name, last_digest) VALUES (?, ?)`, channel, formatTime(ch.LastDigest)); err != nil {
+			return err
+		}
+		for url, fs := range ch.Feeds {
+			key := [2]string{channel, url}
+			encoded := encodeFeed(fs)
+			saved[key] = encoded
+			if s.saved[key] == encoded {
+				continue
+			}
+			if err := saveFeed(tx, channel, url, fs); err != nil {
+				return fmt.Errorf("failed to save %s: %w", url, err)
+			}
+		}
+	}
+	for key := range s.saved {
+		if _, ok := saved[key]; ok {
+			continue
+		}
+		for _, table := range []string{"feeds", "seen_items"} {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE channel = ? AND url = ?`, key[0], key[1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.saved = saved
+	return nil
+}
+
+func saveFeed(tx *sql.Tx, channel, url string, fs FeedState) error {
+	pending, err := json.Marshal(fs.Pending)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO feeds (channel, url, last_updated, etag, last_modified, last_checked, next_due, pending, failures, last_error, last_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		channel, url, formatTime(fs.LastUpdated), fs.ETag, fs.LastModified, formatTime(fs.LastChecked), formatTime(fs.NextDue), string(pending),
+		fs.Failures, fs.LastError, fs.LastStatus)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM seen_items WHERE channel = ? AND url = ?`, channel, url); err != nil {
+		return err
+	}
+	for i, id := range fs.SeenIDs {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO seen_items (channel, url, item_id, position) VALUES (?, ?, ?, ?)`, channel, url, id, i); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range fs.Delivered {
+		_, err := tx.Exec(`
+			INSERT INTO deliveries (channel, url, item_id, title, link, delivered_at, hash, channel_id, ts, thread)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (channel, url, item_id, delivered_at) DO UPDATE SET title = excluded.title, link = excluded.link, hash = excluded.hash`,
+			channel, url, d.ItemID, d.Title, d.Link, formatTime(d.At), d.Hash, d.ChannelID, d.TS, d.Thread)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History returns every recorded delivery for a feed, oldest first.
+func (s *SQLiteStore) History(channel, url string) ([]Delivery, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT item_id, title, link, delivered_at, hash, channel_id, ts, thread FROM deliveries WHERE channel = ? AND url = ? ORDER BY id`, channel, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []Delivery
+	for rows.Next() {
+		var d Delivery
+		var at string
+		if err := rows.Scan(&d.ItemID, &d.Title, &d.Link, &at, &d.Hash, &d.ChannelID, &d.TS, &d.Thread); err != nil {
+			return nil, err
+		}
+		if d.At, err = parseTime(at); err != nil {
+			return nil, err
+		}
+		history = append(history, d)
+	}
+	return history, rows.Err()
+}
+
+func encodeFeed(fs FeedState) string {
+	// FeedState only holds types that always encode
+	data, _ := json.Marshal(fs)
+	return string(data)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -1,40 +1,279 @@
 package state
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
 	"os"
+	"path/filepath"
 	"time"
+
+	"slack-rss-feed-manager/rss"
 )
 
 type State struct {
+	// Version is the schema version the state was saved with; see migrate.go.
+	Version  int
 	Channels map[string]ChannelState
 }
 
 type ChannelState struct {
 	Feeds map[string]FeedState
+	// LastDigest is when the channel's digest schedule last fired.
+	LastDigest time.Time
+}
+
+// MarshalJSON leaves out LastDigest for channels without a digest.
+func (c ChannelState) MarshalJSON() ([]byte, error) {
+	type plain ChannelState
+	return json.Marshal(struct {
+		plain
+		LastDigest *time.Time `json:",omitempty"`
+	}{plain(c), optionalTime(c.LastDigest)})
 }
 
 type FeedState struct {
 	LastUpdated time.Time
+	// HTTP validators from the last successful fetch, used for conditional requests.
+	ETag         string `json:",omitempty"`
+	LastModified string `json:",omitempty"`
+	// SeenIDs holds the IDs of recently seen items, oldest first.
+	SeenIDs []string `json:",omitempty"`
+	// LastChecked is when the feed was last fetched successfully, and NextDue
+	// when it should next be fetched. A zero NextDue means every run.
+	LastChecked time.Time
+	NextDue     time.Time
+	// Failures counts the fetches in a row that failed, LastError is the error
+	// of the latest one and LastStatus the HTTP status of the latest fetch, or
+	// zero if it got no response.
+	// LastChecked doubles as the time of the last successful fetch.
+	Failures   int    `json:",omitempty"`
+	LastError  string `json:",omitempty"`
+	LastStatus int    `json:",omitempty"`
+	// Pending holds items that could not be delivered yet, oldest first.
+	Pending []PendingItem `json:",omitempty"`
+	// Delivered holds the most recent deliveries, oldest first.
+	Delivered []Delivery `json:",omitempty"`
+}
+
+// MarshalJSON leaves out LastChecked and NextDue while they are zero, like
+// the other optional fields, so feeds that were never checked or have no
+// poll interval don't carry placeholder dates.
+func (f FeedState) MarshalJSON() ([]byte, error) {
+	type plain FeedState
+	return json.Marshal(struct {
+		plain
+		LastChecked *time.Time `json:",omitempty"`
+		NextDue     *time.Time `json:",omitempty"`
+	}{plain(f), optionalTime(f.LastChecked), optionalTime(f.NextDue)})
 }
 
+// optionalTime returns nil for the zero time, for fields omitted when unset.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// MaxDeliveries bounds how many deliveries are kept per feed in State. Stores
+// may keep a longer history.
+const MaxDeliveries = 50
+
+// Delivery records an item that was posted.
+type Delivery struct {
+	ItemID string
+	Title  string
+	Link   string
+	At     time.Time
+	// Hash is the ContentHash of the item as it was posted, or as it was last
+	// updated to.
+	Hash string `json:",omitempty"`
+	// ChannelID and TS identify the Slack message of items posted by the bot,
+	// and Thread is the timestamp of the thread it replies in, if any.
+	ChannelID string `json:",omitempty"`
+	TS        string `json:",omitempty"`
+	Thread    string `json:",omitempty"`
+}
+
+// NewDelivery records item as posted at at.
+func NewDelivery(item rss.FeedItem, at time.Time) Delivery {
+	return Delivery{ItemID: item.ID, Title: item.Title, Link: item.Link, At: at, Hash: ContentHash(item)}
+}
+
+// ContentHash sums up the parts of an item that show in its message, so that
+// edits to a posted item can be noticed.
+func ContentHash(item rss.FeedItem) string {
+	sum := sha256.Sum256([]byte(item.Title + "\x00" + item.Link + "\x00" + item.Summary))
+	return hex.EncodeToString(sum[:16])
+}
+
+// RecordDelivery adds d to the delivery history, dropping the oldest entries
+// beyond MaxDeliveries.
+func (f *FeedState) RecordDelivery(d Delivery) {
+	f.Delivered = append(f.Delivered, d)
+	if excess := len(f.Delivered) - MaxDeliveries; excess > 0 {
+		f.Delivered = f.Delivered[excess:]
+	}
+}
+
+// PendingItem is an item not delivered yet: either its delivery failed and is
+// retried on later runs, or it is held to be sent together with other items.
+type PendingItem struct {
+	Item      rss.FeedItem
+	Attempts  int
+	LastError string
+}
+
+// AddPending records item as undelivered after its first failed attempt.
+func (f *FeedState) AddPending(item rss.FeedItem, err error) {
+	f.Pending = append(f.Pending, PendingItem{Item: item, Attempts: 1, LastError: err.Error()})
+}
+
+// Hold records item as not delivered yet without counting an attempt.
+func (f *FeedState) Hold(item rss.FeedItem) {
+	f.Pending = append(f.Pending, PendingItem{Item: item})
+}
+
+// Due reports whether the feed should be fetched at now.
+func (f FeedState) Due(now time.Time) bool {
+	return !now.Before(f.NextDue)
+}
+
+// MaxSeenIDs bounds how many item IDs are remembered per feed.
+const MaxSeenIDs = 500
+
+// Seen returns the remembered item IDs as a set.
+func (f FeedState) Seen() map[string]bool {
+	seen := make(map[string]bool, len(f.SeenIDs))
+	for _, id := range f.SeenIDs {
+		seen[id] = true
+	}
+	return seen
+}
+
+// MarkSeen records the IDs of the items currently in the feed. They are moved
+// to the end of SeenIDs so that the oldest entries are evicted first, and IDs
+// still present in the feed are never evicted even if that exceeds MaxSeenIDs.
+func (f *FeedState) MarkSeen(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(ids))
+	var fresh []string
+	for _, id := range ids {
+		if !current[id] {
+			current[id] = true
+			fresh = append(fresh, id)
+		}
+	}
+	var kept []string
+	for _, id := range f.SeenIDs {
+		if !current[id] {
+			kept = append(kept, id)
+		}
+	}
+	if excess := len(kept) + len(fresh) - MaxSeenIDs; excess > 0 {
+		kept = kept[min(excess, len(kept)):]
+	}
+	f.SeenIDs = append(kept, fresh...)
+}
+
+// LoadState reads the state file, upgrading it if it was saved by an older
+// version. A missing file is fresh state; any other read or decode error is
+// returned, since carrying on with empty state would repost every feed.
 func LoadState(filePath string) (State, error) {
 	data, err := os.ReadFile(filePath)
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) {
 		return State{Channels: make(map[string]ChannelState)}, nil
 	}
+	if err != nil {
+		return State{}, err
+	}
+	if !json.Valid(data) {
+		return State{}, fmt.Errorf("state file %s is corrupt (the previous version is in %s)", filePath, BackupPath(filePath))
+	}
+	data, version, err := migrate(data, migrations)
+	if err != nil {
+		return State{}, fmt.Errorf("state file %s: %w", filePath, err)
+	}
+	if version != CurrentVersion {
+		log.Printf("Upgraded state file %s from version %d to %d", filePath, version, CurrentVersion)
+	}
 	var state State
-	err = json.Unmarshal(data, &state)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("state file %s is corrupt (the previous version is in %s): %w", filePath, BackupPath(filePath), err)
+	}
 	if state.Channels == nil {
 		state.Channels = make(map[string]ChannelState)
 	}
-	return state, err
+	return state, nil
+}
+
+// BackupPath returns where Save keeps the previous version of a state file.
+func BackupPath(filePath string) string {
+	return filePath + ".bak"
 }
 
+// Save writes the state atomically: the new version is written and synced to
+// a temporary file that then replaces the old one, so a crash leaves either
+// the old or the new state but never a truncated file. The old version is
+// kept at BackupPath.
 func (s *State) Save(filePath string) error {
+	s.Version = CurrentVersion
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, data, 0644)
+	previous, err := os.ReadFile(filePath)
+	if err == nil {
+		if err := writeFileAtomic(BackupPath(filePath), previous); err != nil {
+			return fmt.Errorf("failed to back up state: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writeFileAtomic(filePath, data)
+}
+
+// writeFileAtomic replaces filePath with data via a synced temporary file in
+// the same directory.
+func writeFileAtomic(filePath string, data []byte) error {
+	dir := filepath.Dir(filePath)
+	tmp, err := os.CreateTemp(dir, filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Cleans up after a failure; after the rename the temp file is gone
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform supports this, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
 }
@@ -0,0 +1,239 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func TestMarkSeen(t *testing.T) {
+	t.Run("moves current items to the end", func(t *testing.T) {
+		fs := FeedState{SeenIDs: []string{"a", "b", "c"}}
+		fs.MarkSeen([]string{"b", "d", "d"})
+
+		expected := []string{"a", "c", "b", "d"}
+		if fmt.Sprint(fs.SeenIDs) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v, got %v", expected, fs.SeenIDs)
+		}
+	})
+
+	t.Run("evicts oldest beyond limit", func(t *testing.T) {
+		var fs FeedState
+		for i := 0; i < MaxSeenIDs; i++ {
+			fs.SeenIDs = append(fs.SeenIDs, fmt.Sprintf("old-%d", i))
+		}
+		fs.MarkSeen([]string{"new-1", "new-2"})
+
+		if len(fs.SeenIDs) != MaxSeenIDs {
+			t.Fatalf("Expected %d IDs, got %d", MaxSeenIDs, len(fs.SeenIDs))
+		}
+		seen := fs.Seen()
+		if seen["old-0"] || seen["old-1"] {
+			t.Error("Expected oldest IDs to be evicted")
+		}
+		if !seen["old-2"] || !seen["new-1"] || !seen["new-2"] {
+			t.Error("Expected newer IDs to be kept")
+		}
+	})
+
+	t.Run("keeps all current items", func(t *testing.T) {
+		fs := FeedState{SeenIDs: []string{"old"}}
+		var ids []string
+		for i := 0; i < MaxSeenIDs+10; i++ {
+			ids = append(ids, fmt.Sprintf("id-%d", i))
+		}
+		fs.MarkSeen(ids)
+
+		if len(fs.SeenIDs) != len(ids) {
+			t.Errorf("Expected %d IDs, got %d", len(ids), len(fs.SeenIDs))
+		}
+		if fs.Seen()["old"] {
+			t.Error("Expected old ID to be evicted")
+		}
+	})
+}
+
+func TestPendingRoundTrip(t *testing.T) {
+	published := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	var fs FeedState
+	fs.AddPending(rss.FeedItem{ID: "a", Title: "Post", Published: published}, errors.New("rate limited"))
+	fs.Hold(rss.FeedItem{ID: "b", Title: "Held"})
+
+	s := State{Channels: map[string]ChannelState{"general": {Feeds: map[string]FeedState{"http://example.com/feed": fs}}}}
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := loaded.Channels["general"].Feeds["http://example.com/feed"].Pending
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 pending items, got %d", len(pending))
+	}
+	p := pending[0]
+	if p.Item.ID != "a" || !p.Item.Published.Equal(published) || p.Attempts != 1 || p.LastError != "rate limited" {
+		t.Errorf("Unexpected pending item %+v", p)
+	}
+	if held := pending[1]; held.Item.ID != "b" || held.Attempts != 0 || held.LastError != "" {
+		t.Errorf("Expected a held item without attempts, got %+v", held)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	item := rss.FeedItem{ID: "a", Title: "Title", Link: "http://example.com/a", Summary: "Summary"}
+	d := NewDelivery(item, time.Now())
+	if d.Hash == "" || d.Hash != ContentHash(item) {
+		t.Errorf("Expected the delivery to carry the item's hash, got %q", d.Hash)
+	}
+
+	same := item
+	same.Published = time.Now()
+	if ContentHash(same) != d.Hash {
+		t.Error("Expected fields that don't show in the message to be ignored")
+	}
+	for _, edit := range []func(*rss.FeedItem){
+		func(i *rss.FeedItem) { i.Title = "Renamed" },
+		func(i *rss.FeedItem) { i.Link = "http://example.com/b" },
+		func(i *rss.FeedItem) { i.Summary = "Rewritten" },
+		// The separators keep text moving between fields from going unnoticed
+		func(i *rss.FeedItem) { i.Title, i.Link = "Titlehttp://example.com/a", "" },
+	} {
+		edited := item
+		edit(&edited)
+		if ContentHash(edited) == d.Hash {
+			t.Errorf("Expected a different hash for %+v", edited)
+		}
+	}
+}
+
+func TestLoadState(t *testing.T) {
+	t.Run("missing file is fresh state", func(t *testing.T) {
+		s, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if s.Channels == nil || len(s.Channels) != 0 {
+			t.Errorf("Expected empty state, got %+v", s)
+		}
+	})
+
+	t.Run("corrupt file is an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		if err := os.WriteFile(path, []byte(`{"Channels": {`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadState(path); err == nil {
+			t.Error("Expected error for corrupt state, got nil")
+		}
+	})
+
+	t.Run("unreadable file is an error", func(t *testing.T) {
+		// A directory in place of the file can't be read, even as root
+		path := t.TempDir()
+		if _, err := LoadState(path); err == nil {
+			t.Error("Expected error for unreadable state, got nil")
+		}
+	})
+}
+
+func TestFeedStateJSON(t *testing.T) {
+	at := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	data, err := json.Marshal(FeedState{LastUpdated: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"LastUpdated":"2025-07-25T12:00:00Z"}` {
+		t.Errorf("Expected unset times to be left out, got %s", data)
+	}
+
+	data, err = json.Marshal(ChannelState{Feeds: map[string]FeedState{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Feeds":{}}` {
+		t.Errorf("Expected an unset LastDigest to be left out, got %s", data)
+	}
+
+	want := FeedState{LastUpdated: at, LastChecked: at.Add(time.Minute), NextDue: at.Add(time.Hour), SeenIDs: []string{"a"}}
+	data, err = json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got FeedState
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Round trip through %s = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	first := State{Channels: map[string]ChannelState{"first": {}}}
+	second := State{Channels: map[string]ChannelState{"second": {}}}
+
+	if err := first.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(BackupPath(path)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no backup after the first save, got %v", err)
+	}
+	if err := second.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := current.Channels["second"]; !ok {
+		t.Errorf("Expected saved state, got %+v", current)
+	}
+	backup, err := LoadState(BackupPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backup.Channels["first"]; !ok {
+		t.Errorf("Expected previous state in backup, got %+v", backup)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected only the state file and its backup, got %d files", len(entries))
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	lock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked while held, got %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Expected lock after unlock, got %v", err)
+	}
+	lock.Unlock()
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Store persists State between runs.
+type Store interface {
+	// Load returns the saved state, or empty state if nothing was saved yet.
+	Load() (State, error)
+	// Save persists s, replacing what was saved before.
+	Save(s *State) error
+	Close() error
+}
+
+// Open returns the store for path: an SQLite database for the extensions
+// .db, .sqlite and .sqlite3, and a JSON file otherwise.
+func Open(path string) (Store, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return OpenSQLite(path)
+	default:
+		return NewJSONStore(path), nil
+	}
+}
+
+// OpenReadOnly returns the store for path like Open, for callers that only
+// read state. An SQLite database is opened with OpenSQLiteReadOnly, so it is
+// neither created nor upgraded.
+func OpenReadOnly(path string) (Store, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return OpenSQLiteReadOnly(path)
+	default:
+		return NewJSONStore(path), nil
+	}
+}
+
+// JSONStore keeps the whole state in a single JSON file, rewritten on every
+// save.
+type JSONStore struct {
+	path string
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{path: path}
+}
+
+func (j *JSONStore) Load() (State, error) {
+	return LoadState(j.path)
+}
+
+func (j *JSONStore) Save(s *State) error {
+	return s.Save(j.path)
+}
+
+func (j *JSONStore) Close() error {
+	return nil
+}
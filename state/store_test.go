@@ -0,0 +1,335 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-rss-feed-manager/rss"
+)
+
+func sampleState() State {
+	at := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	feed := FeedState{
+		LastUpdated:  at,
+		ETag:         `"abc"`,
+		LastModified: "Fri, 25 Jul 2025 12:00:00 GMT",
+		SeenIDs:      []string{"c", "a", "b"},
+		LastChecked:  at.Add(time.Minute),
+		NextDue:      at.Add(time.Hour),
+		Failures:     2,
+		LastError:    "unexpected HTTP status 503 Service Unavailable",
+		LastStatus:   503,
+	}
+	feed.AddPending(rss.FeedItem{ID: "d", Title: "Pending", Link: "http://example.com/d", Published: at}, errors.New("rate limited"))
+	delivery := NewDelivery(rss.FeedItem{ID: "b", Title: "Posted", Link: "http://example.com/b"}, at.Add(2*time.Minute))
+	delivery.ChannelID, delivery.TS, delivery.Thread = "C123", "1721908800.000200", "1721908800.000100"
+	feed.RecordDelivery(delivery)
+	return State{Channels: map[string]ChannelState{
+		"general": {Feeds: map[string]FeedState{
+			"http://example.com/feed": feed,
+			"http://example.com/new":  {LastUpdated: at},
+		}, LastDigest: at.Add(-24 * time.Hour)},
+		"empty": {Feeds: map[string]FeedState{}},
+	}}
+}
+
+func TestStores(t *testing.T) {
+	for _, ext := range []string{".json", ".db"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state"+ext)
+			store, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			empty, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load() of new store: %v", err)
+			}
+			if len(empty.Channels) != 0 {
+				t.Errorf("Expected empty state, got %+v", empty)
+			}
+
+			want := sampleState()
+			if err := store.Save(&want); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			// A fresh store sees what the first one saved
+			reopened, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reopened.Close()
+			got, err := reopened.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %+v, want %+v", got, want)
+			}
+
+			// Removing a feed and a channel is saved too
+			delete(got.Channels["general"].Feeds, "http://example.com/new")
+			delete(got.Channels, "empty")
+			if err := reopened.Save(&got); err != nil {
+				t.Fatal(err)
+			}
+			again, err := reopened.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("Load() after removal = %+v, want %+v", again, got)
+			}
+		})
+	}
+}
+
+func TestSQLiteHistory(t *testing.T) {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	feedURL := "http://example.com/feed"
+	s := State{Channels: map[string]ChannelState{"general": {Feeds: map[string]FeedState{}}}}
+	start := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	total := MaxDeliveries + 10
+	// Save in two batches; the second trims State's copy to the latest MaxDeliveries
+	for _, batch := range [][2]int{{0, MaxDeliveries}, {MaxDeliveries, total}} {
+		fs := s.Channels["general"].Feeds[feedURL]
+		for i := batch[0]; i < batch[1]; i++ {
+			fs.RecordDelivery(NewDelivery(rss.FeedItem{ID: fmt.Sprint(i)}, start.Add(time.Duration(i)*time.Minute)))
+		}
+		s.Channels["general"].Feeds[feedURL] = fs
+		if err := store.Save(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := store.History("general", feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != total {
+		t.Errorf("Expected the full history of %d deliveries, got %d", total, len(history))
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivered := loaded.Channels["general"].Feeds[feedURL].Delivered
+	if len(delivered) != MaxDeliveries || !delivered[len(delivered)-1].At.Equal(start.Add(time.Duration(total-1)*time.Minute)) {
+		t.Errorf("Expected the latest %d deliveries in state, got %d", MaxDeliveries, len(delivered))
+	}
+}
+
+func TestSQLiteUpdatesEditedDeliveries(t *testing.T) {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := sampleState()
+	if err := store.Save(&s); err != nil {
+		t.Fatal(err)
+	}
+	feed := s.Channels["general"].Feeds["http://example.com/feed"]
+	edited := rss.FeedItem{ID: "b", Title: "Renamed", Link: "http://example.com/b"}
+	feed.Delivered[0].Title, feed.Delivered[0].Hash = edited.Title, ContentHash(edited)
+	if err := store.Save(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := store.History("general", "http://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0] != feed.Delivered[0] {
+		t.Errorf("Expected the delivery to be updated in place, got %+v", history)
+	}
+}
+
+func TestSQLiteSavesOnlyChangedFeeds(t *testing.T) {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := sampleState()
+	if err := store.Save(&s); err != nil {
+		t.Fatal(err)
+	}
+	// Change a row behind the store's back; saving unchanged state must not rewrite it
+	if _, err := store.db.Exec(`UPDATE feeds SET etag = 'outside' WHERE url = ?`, "http://example.com/feed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(&s); err != nil {
+		t.Fatal(err)
+	}
+	var etag string
+	if err := store.db.QueryRow(`SELECT etag FROM feeds WHERE url = ?`, "http://example.com/feed").Scan(&etag); err != nil {
+		t.Fatal(err)
+	}
+	if etag != "outside" {
+		t.Errorf("Expected unchanged feed to be skipped, but it was rewritten")
+	}
+}
+
+func TestSQLiteRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if _, err := OpenSQLite(path); err == nil {
+		t.Error("Expected error for newer schema version, got nil")
+	}
+}
+
+func TestSQLiteUpgradesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The version 1 schema, before feed health was tracked, digests were sent
+	// and posted messages were kept
+	v1 := sqliteSchema
+	for _, column := range []string{"failures", "last_error", "last_status", "last_digest", "hash", "channel_id", "ts", "thread"} {
+		v1 = regexp.MustCompile(`(?m)^\s*`+column+` .*\n`).ReplaceAllString(v1, "")
+	}
+	v1 = strings.Replace(v1, "PRIMARY KEY,\n);", "PRIMARY KEY\n);", 1)
+	if _, err := db.Exec(v1 + `PRAGMA user_version = 1;`); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO channels (name) VALUES ('general');
+		INSERT INTO feeds (channel, url, last_updated, last_checked, next_due)
+		VALUES ('general', 'http://example.com/feed', '2025-07-25T12:00:00Z', '0001-01-01T00:00:00Z', '0001-01-01T00:00:00Z');
+		INSERT INTO deliveries (channel, url, item_id, title, link, delivered_at)
+		VALUES ('general', 'http://example.com/feed', 'a', 'Posted', 'http://example.com/a', '2025-07-25T12:00:00Z');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer store.Close()
+	s, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	feed, ok := s.Channels["general"].Feeds["http://example.com/feed"]
+	if !ok || feed.Failures != 0 || !feed.LastUpdated.Equal(time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected feed after upgrade: %+v", feed)
+	}
+	if len(feed.Delivered) != 1 || feed.Delivered[0].Title != "Posted" || feed.Delivered[0].Hash != "" {
+		t.Errorf("Unexpected deliveries after upgrade: %+v", feed.Delivered)
+	}
+
+	feed.Failures = 1
+	s.Channels["general"].Feeds["http://example.com/feed"] = feed
+	if err := store.Save(&s); err != nil {
+		t.Fatalf("Save() after upgrade error = %v", err)
+	}
+}
+
+func TestSQLiteReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("missing database", func(t *testing.T) {
+		path := filepath.Join(dir, "missing.db")
+		store, err := OpenReadOnly(path)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		defer store.Close()
+		s, err := store.Load()
+		if err != nil || len(s.Channels) != 0 {
+			t.Errorf("Expected empty state, got %+v, %v", s, err)
+		}
+		if err := store.Save(&s); err == nil {
+			t.Error("Expected Save to fail on a read-only store")
+		}
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected no database to be created, got %v", err)
+		}
+	})
+
+	t.Run("existing database", func(t *testing.T) {
+		path := filepath.Join(dir, "state.db")
+		writable, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sampleState()
+		if err := writable.Save(&want); err != nil {
+			t.Fatal(err)
+		}
+		writable.Close()
+
+		store, err := OpenReadOnly(path)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		defer store.Close()
+		got, err := store.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Load() = %+v, want %+v", got, want)
+		}
+		if err := store.Save(&got); err == nil {
+			t.Error("Expected Save to fail on a read-only store")
+		}
+	})
+
+	t.Run("older schema", func(t *testing.T) {
+		path := filepath.Join(dir, "old.db")
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`CREATE TABLE channels (name TEXT PRIMARY KEY); PRAGMA user_version = 1;`); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		if _, err := OpenReadOnly(path); err == nil {
+			t.Error("Expected an error for an older schema version, got nil")
+		}
+		db, err = sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		var version int
+		if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil || version != 1 {
+			t.Errorf("Expected the database to be left at version 1, got %d, %v", version, err)
+		}
+	})
+}